@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	abdMetrics = map[string]kstatMetric{
+		"struct_size": {
+			prometheus.NewDesc(
+				"zfs_abd_struct_size_bytes",
+				"memory occupied by all abd_t structs in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"linear_cnt": {
+			prometheus.NewDesc(
+				"zfs_abd_linear_count",
+				"number of linear ABDs currently allocated",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"linear_data_size": {
+			prometheus.NewDesc(
+				"zfs_abd_linear_data_bytes",
+				"data stored in all linear ABDs in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"scatter_cnt": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_count",
+				"number of scatter ABDs currently allocated",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"scatter_data_size": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_data_bytes",
+				"data stored in all scatter ABDs in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"scatter_chunk_waste": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_chunk_waste_bytes",
+				"space wasted at the end of the last chunk of all scatter ABDs in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"scatter_page_multi_chunk": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_page_multi_chunk",
+				"number of scatter ABDs made up of more than one chunk",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"scatter_page_multi_zone": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_page_multi_zone",
+				"number of scatter ABDs split across memory zones",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"scatter_page_alloc_retry": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_page_alloc_retry_total",
+				"number of retries allocating pages for scatter ABDs",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+		"scatter_sg_table_retry": {
+			prometheus.NewDesc(
+				"zfs_abd_scatter_sg_table_retry_total",
+				"number of retries allocating the scatter/gather table for ABDs",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+	}
+
+	abdScatterOrder = kstatIndexedMetric{
+		prefix: "scatter_order_",
+		kstatMetric: kstatMetric{
+			prometheus.NewDesc(
+				"zfs_abd_scatter_order_count",
+				"number of scatter ABD page allocations by allocation order",
+				[]string{"order"}, nil,
+			),
+			prometheus.GaugeValue,
+		},
+	}
+)
+
+// NewABDCollector exports the ARC buffer data (ABD) statistics from abdstats.
+func NewABDCollector(procfs string) *KstatCollector {
+	return newKstatCollector(procfs, "abdstats", abdMetrics, abdScatterOrder)
+}
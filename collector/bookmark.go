@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+// bookmarkSummary aggregates the bookmarks of a single dataset, which are
+// exported with the labels of the dataset once it has been collected.
+type bookmarkSummary struct {
+	bookmarks []bookmark
+	newest    uint64
+}
+
+type bookmark struct {
+	// name is the part of the bookmark name after the '#'
+	name    string
+	created uint64
+	txg     uint64
+	guid    uint64
+}
+
+type bookmarkDescs struct {
+	info       *prometheus.Desc
+	createdAt  *prometheus.Desc
+	createdTXG *prometheus.Desc
+	count      *prometheus.Desc
+	newest     *prometheus.Desc
+}
+
+func newBookmarkDescs(b *descBuilder) bookmarkDescs {
+	return bookmarkDescs{
+		info: b.desc(
+			"zfs_bookmark_info",
+			"bookmark of a snapshot of the dataset, identified by the guid of the snapshot it was created from",
+			"bookmark", "guid",
+		),
+		createdAt: b.desc(
+			"zfs_bookmark_created_timestamp_seconds",
+			"Unix timestamp of the creation of the snapshot that the bookmark was created from",
+			"bookmark",
+		),
+		createdTXG: b.desc(
+			"zfs_bookmark_created_txg",
+			"transaction group of the snapshot that the bookmark was created from",
+			"bookmark",
+		),
+		count: b.desc(
+			"zfs_dataset_bookmarks_count",
+			"number of bookmarks of the dataset, excluding its descendents",
+		),
+		newest: b.desc(
+			"zfs_dataset_bookmarks_newest_timestamp_seconds",
+			"Unix timestamp of the creation of the newest bookmark of the dataset",
+		),
+	}
+}
+
+func (descs bookmarkDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.info
+	ch <- descs.createdAt
+	ch <- descs.createdTXG
+	ch <- descs.count
+	ch <- descs.newest
+}
+
+// summariseBookmark adds a bookmark to the summary of its dataset. Errors are
+// counted against the dataset.
+func (collector *DatasetCollector) summariseBookmark(summaries map[string]*bookmarkSummary, dataset *zfs.Dataset) {
+	name := dataset.Name()
+	parent, short, _ := strings.Cut(name, "#")
+
+	vals, err := dataset.Gets(zfs.DatasetPropCreation, zfs.DatasetPropCreateTXG, zfs.DatasetPropGUID)
+	if err != nil {
+		collector.datasetErrors[parent]++
+		log.Printf("error reading bookmark properties of '%s': %v", name, err)
+		return
+	}
+	created := vals[zfs.DatasetPropCreation].(*zfs.DatasetPropertyNumber).Value()
+
+	summary, ok := summaries[parent]
+	if !ok {
+		summary = &bookmarkSummary{}
+		summaries[parent] = summary
+	}
+	summary.bookmarks = append(summary.bookmarks, bookmark{
+		name:    short,
+		created: created,
+		txg:     vals[zfs.DatasetPropCreateTXG].(*zfs.DatasetPropertyNumber).Value(),
+		guid:    vals[zfs.DatasetPropGUID].(*zfs.DatasetPropertyNumber).Value(),
+	})
+	summary.newest = max(summary.newest, created)
+}
+
+// collectBookmarks exports the bookmarks of a dataset and their summary,
+// labelled with the labels of the dataset.
+func (collector *DatasetCollector) collectBookmarks(ch chan<- prometheus.Metric, summary *bookmarkSummary, labels []string) {
+	if summary == nil {
+		ch <- prometheus.MustNewConstMetric(collector.bookmarks.count, prometheus.GaugeValue, 0, labels...)
+		return
+	}
+
+	for _, bm := range summary.bookmarks {
+		bmLabels := append(labels[:len(labels):len(labels)], bm.name)
+		ch <- prometheus.MustNewConstMetric(collector.bookmarks.info, prometheus.GaugeValue, 1,
+			append(bmLabels, strconv.FormatUint(bm.guid, 10))...)
+		ch <- prometheus.MustNewConstMetric(collector.bookmarks.createdAt, prometheus.GaugeValue, float64(bm.created),
+			bmLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.bookmarks.createdTXG, prometheus.GaugeValue, float64(bm.txg),
+			bmLabels...)
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.bookmarks.count, prometheus.GaugeValue, float64(len(summary.bookmarks)), labels...)
+	ch <- prometheus.MustNewConstMetric(collector.bookmarks.newest, prometheus.GaugeValue, float64(summary.newest), labels...)
+}
@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"log"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+type cloneDescs struct {
+	origin *prometheus.Desc
+	unique *prometheus.Desc
+	shared *prometheus.Desc
+	// clones and info are of snapshots
+	clones *prometheus.Desc
+	info   *prometheus.Desc
+}
+
+func newCloneDescs(b *descBuilder) cloneDescs {
+	return cloneDescs{
+		origin: b.desc(
+			"zfs_dataset_origin_info",
+			"snapshot that a clone was created from",
+			"origin",
+		),
+		unique: b.desc(
+			"zfs_dataset_clone_unique_bytes",
+			"space referenced by a clone that was written since it was created from its origin in bytes",
+		),
+		shared: b.desc(
+			"zfs_dataset_clone_shared_bytes",
+			"space referenced by a clone that is still shared with its origin in bytes",
+		),
+		clones: b.desc(
+			"zfs_snapshot_clones",
+			"number of clones of the snapshot, which prevent it from being destroyed; only snapshots with clones are exported",
+		),
+		info: b.desc(
+			"zfs_snapshot_clone_info",
+			"clone of the snapshot",
+			"clone",
+		),
+	}
+}
+
+func (descs cloneDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.origin
+	ch <- descs.unique
+	ch <- descs.shared
+	ch <- descs.clones
+	ch <- descs.info
+}
+
+// collectOrigin exports the origin of a clone, and how much of it has
+// diverged from that origin. Datasets that aren't clones are skipped.
+func (collector *DatasetCollector) collectOrigin(ch chan<- prometheus.Metric, dataset *zfs.Dataset, labels []string) {
+	name := dataset.Name()
+
+	origin, err := dataset.Origin()
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading origin of dataset '%s': %v", name, err)
+		return
+	}
+	if origin == "" {
+		return
+	}
+	val, err := dataset.Get(zfs.DatasetPropReferenced)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading referenced space of clone '%s': %v", name, err)
+		return
+	}
+	referenced := val.(*zfs.DatasetPropertyNumber).Value()
+
+	ch <- prometheus.MustNewConstMetric(
+		collector.clones.origin, prometheus.GaugeValue, 1,
+		append(labels[:len(labels):len(labels)], origin)...,
+	)
+
+	unique, err := dataset.WrittenSince(origin)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading space written to clone: %v", err)
+		return
+	}
+	shared := uint64(0)
+	if referenced > unique {
+		shared = referenced - unique
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.clones.unique, prometheus.GaugeValue, float64(unique), labels...)
+	ch <- prometheus.MustNewConstMetric(collector.clones.shared, prometheus.GaugeValue, float64(shared), labels...)
+}
+
+// collectSnapshotClones exports the clones of a snapshot, if it has any.
+// Errors are counted against the dataset the snapshot is of, as not every
+// snapshot is exported individually.
+func (collector *DatasetCollector) collectSnapshotClones(ch chan<- prometheus.Metric, snapshot *zfs.Dataset, labels []string) {
+	name := snapshot.Name()
+	parent := name[:strings.Index(name, "@")]
+
+	// The clones property fails rather than being empty without any clones
+	val, err := snapshot.Get(zfs.DatasetPropNumClones)
+	if err != nil {
+		collector.datasetErrors[parent]++
+		log.Printf("error reading number of clones of '%s': %v", name, err)
+		return
+	}
+	count := val.(*zfs.DatasetPropertyNumber).Value()
+	if count == 0 {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.clones.clones, prometheus.GaugeValue, float64(count), labels...)
+
+	val, err = snapshot.Get(zfs.DatasetPropClones)
+	if err != nil {
+		collector.datasetErrors[parent]++
+		log.Printf("error reading clones of '%s': %v", name, err)
+		return
+	}
+	for _, clone := range strings.Split(datasetPropertyValue(val), ",") {
+		ch <- prometheus.MustNewConstMetric(
+			collector.clones.info, prometheus.GaugeValue, 1,
+			append(labels[:len(labels):len(labels)], clone)...,
+		)
+	}
+}
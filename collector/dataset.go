@@ -1,9 +1,12 @@
 package collector
 
 import (
+	"fmt"
 	"log"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -13,59 +16,6 @@ import (
 var (
 	datasetLabels = []string{"name", "pool", "type", "dataset"}
 
-	datasetCreatedAt = prometheus.NewDesc(
-		"zfs_dataset_created_timestamp_seconds",
-		"Unix timestamp representing the created date/time of the dataset",
-		datasetLabels, nil,
-	)
-	datasetUsedBytes = prometheus.NewDesc(
-		"zfs_dataset_used_bytes",
-		"space used by dataset and all its descendents in bytes",
-		datasetLabels, nil,
-	)
-
-	datasetRefBytes = prometheus.NewDesc(
-		"zfs_dataset_referenced_bytes",
-		"",
-		datasetLabels, nil,
-	)
-
-	datasetAvailBytes = prometheus.NewDesc(
-		"zfs_dataset_available_bytes",
-		"space available in the dataset in bytes",
-		datasetLabels, nil,
-	)
-
-	datasetWrittenBytes = prometheus.NewDesc(
-		"zfs_dataset_written_bytes",
-		"",
-		datasetLabels, nil,
-	)
-
-	datasetQuotaBytes = prometheus.NewDesc(
-		"zfs_dataset_quota_bytes",
-		"",
-		datasetLabels, nil,
-	)
-
-	datasetVolsizeBytes = prometheus.NewDesc(
-		"zfs_volume_size_bytes",
-		"size in bytes of a zfs volume",
-		datasetLabels, nil,
-	)
-
-	datasetReadOnly = prometheus.NewDesc(
-		"zfs_dataset_readonly",
-		"",
-		datasetLabels, nil,
-	)
-
-	datasetCompressRatio = prometheus.NewDesc(
-		"zfs_dataset_compress_ratio",
-		"",
-		datasetLabels, nil,
-	)
-
 	datasetCollectErrors = prometheus.NewDesc(
 		"zfs_dataset_collect_errors_total",
 		"errors collecting ZFS dataset metrics",
@@ -73,27 +23,175 @@ var (
 	)
 )
 
+// DatasetCollectorOpts selects the dataset metrics to collect.
+type DatasetCollectorOpts struct {
+	// Properties are exported as a metric each. They must all be in
+	// datasetPropertyMetrics; see ParseDatasetProperties.
+	Properties []zfs.DatasetProperty
+	// InfoProperties are exported as labels on zfs_dataset_properties_info
+	// of each filesystem and volume, and may be of any type.
+	InfoProperties []zfs.DatasetProperty
+	// SourceProperties have their value and source exported as
+	// zfs_dataset_property_source_info, and may be of any type.
+	SourceProperties []zfs.DatasetProperty
+	// Snapshots matching SnapshotFilter are exported individually, like any
+	// other dataset. All snapshots are always summarised per dataset.
+	SnapshotFilter *regexp.Regexp
+	// SnapshotPolicies are checked against the snapshots of every dataset.
+	SnapshotPolicies []SnapshotPolicy
+	// MountinfoPath is the mount table that filesystems are checked against
+	MountinfoPath string
+	// DevPath is where devfs is mounted, to find the device of each volume
+	DevPath string
+	// Bookmarks are exported, and summarised per dataset, if set.
+	Bookmarks bool
+	// UserProperties are added as labels to all dataset metrics other than
+	// the error count.
+	UserProperties []UserPropertyLabel
+}
+
+// UserPropertyLabel maps a user-defined property to a label.
+type UserPropertyLabel struct {
+	Property string
+	Label    string
+}
+
 type DatasetCollector struct {
 	libzfs *zfs.LibZFS
+	opts   DatasetCollectorOpts
+
+	// labels are datasetLabels followed by the opts.UserProperties labels
+	labels []string
+	// descs holds a Desc for each of opts.Properties
+	descs map[zfs.DatasetProperty]*prometheus.Desc
+	// info is labelled with each of opts.InfoProperties
+	info   *prometheus.Desc
+	source *prometheus.Desc
+	// snapshots summarise the snapshots of each dataset
+	snapshots  snapshotSummaryDescs
+	bookmarks  bookmarkDescs
+	volume     *prometheus.Desc
+	encryption encryptionDescs
+	mount      mountDescs
+	clones     cloneDescs
+	holds      holdDescs
+	receive    receiveDescs
 
 	datasetErrors map[string]int
 }
 
 // Describe implements prometheus.Collector.
 func (collector *DatasetCollector) Describe(descs chan<- *prometheus.Desc) {
+	for _, desc := range collector.descs {
+		descs <- desc
+	}
+	if collector.info != nil {
+		descs <- collector.info
+	}
+	if collector.source != nil {
+		descs <- collector.source
+	}
+	descs <- collector.volume
+	collector.encryption.describe(descs)
+	collector.mount.describe(descs)
+	collector.clones.describe(descs)
+	collector.receive.describe(descs)
+	collector.holds.describe(descs)
+	collector.snapshots.describe(descs)
+	if collector.opts.Bookmarks {
+		collector.bookmarks.describe(descs)
+	}
 	descs <- datasetCollectErrors
 }
 
-func NewDatasetCollector(libzfs *zfs.LibZFS) *DatasetCollector {
-	return &DatasetCollector{
+// descBuilder creates the Descs of dataset metrics, which are labelled with
+// the collector's labels followed by any of their own. The labels each metric
+// adds are recorded so that user property labels can be checked against them.
+type descBuilder struct {
+	labels []string
+	// extra maps each label added by a metric to the metric's name
+	extra map[string]string
+}
+
+func (b *descBuilder) desc(name, help string, extra ...string) *prometheus.Desc {
+	for _, label := range extra {
+		if _, ok := b.extra[label]; !ok {
+			b.extra[label] = name
+		}
+	}
+	return prometheus.NewDesc(name, help, append(b.labels[:len(b.labels):len(b.labels)], extra...), nil)
+}
+
+// NewDatasetCollector creates a dataset collector, returning an error if a
+// user property label clashes with a label of any dataset metric.
+func NewDatasetCollector(libzfs *zfs.LibZFS, opts DatasetCollectorOpts) (*DatasetCollector, error) {
+	labels := append([]string{}, datasetLabels...)
+	for _, user := range opts.UserProperties {
+		labels = append(labels, user.Label)
+	}
+	b := &descBuilder{labels: labels, extra: make(map[string]string)}
+
+	descs := make(map[zfs.DatasetProperty]*prometheus.Desc, len(opts.Properties))
+	for _, prop := range opts.Properties {
+		metric := datasetPropertyMetrics[prop]
+		descs[prop] = b.desc(metric.name, metric.help)
+	}
+
+	var info *prometheus.Desc
+	if len(opts.InfoProperties) > 0 {
+		var infoLabels []string
+		for _, prop := range opts.InfoProperties {
+			infoLabels = append(infoLabels, prop.String())
+		}
+		info = b.desc(
+			"zfs_dataset_properties_info",
+			"dataset properties as labels, empty if not applicable to the dataset type",
+			infoLabels...,
+		)
+	}
+
+	var source *prometheus.Desc
+	if len(opts.SourceProperties) > 0 {
+		source = b.desc(
+			"zfs_dataset_property_source_info",
+			"value of a dataset property and where it is set: default, local, inherited (from inherited_from) or received",
+			"property", "value", "source", "inherited_from",
+		)
+	}
+
+	collector := &DatasetCollector{
 		libzfs:        libzfs,
+		opts:          opts,
+		labels:        labels,
+		descs:         descs,
+		info:          info,
+		source:        source,
+		snapshots:     newSnapshotSummaryDescs(b),
+		bookmarks:     newBookmarkDescs(b),
+		volume:        newVolumeInfoDesc(b),
+		encryption:    newEncryptionDescs(b),
+		mount:         newMountDescs(b),
+		clones:        newCloneDescs(b),
+		holds:         newHoldDescs(b),
+		receive:       newReceiveDescs(b),
 		datasetErrors: make(map[string]int),
 	}
+
+	for _, user := range opts.UserProperties {
+		if metric, ok := b.extra[user.Label]; ok {
+			return nil, fmt.Errorf("label name '%s' for user property '%s' is already used by %s", user.Label, user.Property, metric)
+		}
+	}
+	return collector, nil
 }
 
 // Collect implements prometheus.Collector.
 func (collector *DatasetCollector) Collect(ch chan<- prometheus.Metric) {
-	datasets, err := collector.libzfs.DatasetOpenAll(zfs.DatasetTypeFilesystem|zfs.DatasetTypeSnapshot, -1)
+	var types zfs.DatasetType = zfs.DatasetTypeFilesystem | zfs.DatasetTypeVolume | zfs.DatasetTypeSnapshot
+	if collector.opts.Bookmarks {
+		types |= zfs.DatasetTypeBookmark
+	}
+	datasets, err := collector.libzfs.DatasetOpenAll(types, -1)
 
 	if err != nil {
 		log.Printf("error opening datasets: %v", err)
@@ -101,15 +199,67 @@ func (collector *DatasetCollector) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 
+	mounts, err := readMountinfo(collector.opts.MountinfoPath)
+	if err != nil {
+		log.Printf("error reading mount table: %v", err)
+	}
+
+	// Snapshots and bookmarks are summarised per dataset, after all of them
+	// are counted
+	snapshots := make(map[string]*snapshotSummary)
+	bookmarks := make(map[string]*bookmarkSummary)
+	parents := make(map[string][]string)
+	unloaded := make(map[string]int)
 	for _, dataset := range datasets {
-		collector.collectDataset(ch, dataset)
+		name := dataset.Name()
+		switch dataset.Type() {
+		case zfs.DatasetTypeSnapshot:
+			labels := collector.datasetLabelValues(dataset)
+			collector.summariseSnapshot(snapshots, dataset)
+			collector.collectSnapshotClones(ch, dataset, labels)
+			collector.collectSnapshotHolds(ch, dataset, labels)
+			if collector.opts.SnapshotFilter != nil && collector.opts.SnapshotFilter.MatchString(name) {
+				collector.collectDataset(ch, dataset, labels)
+			}
+		case zfs.DatasetTypeBookmark:
+			collector.summariseBookmark(bookmarks, dataset)
+		case zfs.DatasetTypeVolume:
+			parents[name] = collector.datasetLabelValues(dataset)
+			collector.collectDataset(ch, dataset, parents[name])
+			collector.collectVolume(ch, dataset, parents[name])
+			collector.collectEncryption(ch, dataset, parents[name], unloaded)
+			collector.collectOrigin(ch, dataset, parents[name])
+			collector.collectReceive(ch, dataset, parents[name])
+		default:
+			parents[name] = collector.datasetLabelValues(dataset)
+			collector.collectDataset(ch, dataset, parents[name])
+			collector.collectEncryption(ch, dataset, parents[name], unloaded)
+			collector.collectMount(ch, dataset, parents[name], mounts)
+			collector.collectOrigin(ch, dataset, parents[name])
+			collector.collectReceive(ch, dataset, parents[name])
+		}
 		dataset.Close()
 	}
 
+	for pool, count := range unloaded {
+		ch <- prometheus.MustNewConstMetric(poolKeysUnloaded, prometheus.GaugeValue, float64(count), pool)
+	}
+
+	now := time.Now()
+	for name, labels := range parents {
+		collector.collectSnapshotSummary(ch, snapshots[name], labels)
+		collector.collectSnapshotPolicies(ch, name, snapshots[name], labels, now)
+		if collector.opts.Bookmarks {
+			collector.collectBookmarks(ch, bookmarks[name], labels)
+		}
+	}
+
 	runtime.GC()
 }
 
-func (collector *DatasetCollector) collectDataset(metrics chan<- prometheus.Metric, dataset *zfs.Dataset) {
+// datasetLabelValues returns the values of the collector's labels for a
+// dataset. Snapshots are labelled with the dataset they are of.
+func (collector *DatasetCollector) datasetLabelValues(dataset *zfs.Dataset) []string {
 	name := dataset.Name()
 	pool := dataset.Pool().Name()
 	typ := dataset.Type()
@@ -118,36 +268,40 @@ func (collector *DatasetCollector) collectDataset(metrics chan<- prometheus.Metr
 		dsname = name[:strings.Index(name, "@")]
 	}
 
-	if _, ok := collector.datasetErrors[name]; !ok {
-		collector.datasetErrors[name] = 0
+	labels := []string{name, pool, typ.String(), dsname}
+	if len(collector.opts.UserProperties) > 0 {
+		user := dataset.UserProperties()
+		for _, prop := range collector.opts.UserProperties {
+			labels = append(labels, user[prop.Property].Value)
+		}
 	}
+	return labels
+}
 
-	// Common properties for all dataset types (filesystems, volumes, snapshots)
-	descs := map[zfs.DatasetProperty]*prometheus.Desc{
-		zfs.DatasetPropCreation:   datasetCreatedAt,
-		zfs.DatasetPropUsed:       datasetUsedBytes,
-		zfs.DatasetPropReferenced: datasetRefBytes,
-		zfs.DatasetPropWritten:    datasetWrittenBytes,
-	}
+// collectDataset exports the properties of a dataset, labelled with labels.
+func (collector *DatasetCollector) collectDataset(metrics chan<- prometheus.Metric, dataset *zfs.Dataset, labels []string) {
+	name := dataset.Name()
+	typ := dataset.Type()
 
-	// Common properties to filesystem & volume datasets
-	if typ == zfs.DatasetTypeFilesystem || typ == zfs.DatasetTypeVolume {
-		descs[zfs.DatasetPropAvailable] = datasetAvailBytes
-		descs[zfs.DatasetPropCompressratio] = datasetCompressRatio
-		descs[zfs.DatasetPropReadonly] = datasetReadOnly
+	if _, ok := collector.datasetErrors[name]; !ok {
+		collector.datasetErrors[name] = 0
 	}
 
-	// Various random properties specific to each dataset types
-	switch typ {
-	case zfs.DatasetTypeFilesystem:
-		descs[zfs.DatasetPropQuota] = datasetQuotaBytes
-	case zfs.DatasetTypeVolume:
-		descs[zfs.DatasetPropVolsize] = datasetVolsizeBytes
+	// Fetch each property once, even if it's both exported as a metric and
+	// has its source reported
+	lists := [][]zfs.DatasetProperty{collector.opts.Properties, collector.opts.SourceProperties}
+	if typ != zfs.DatasetTypeSnapshot {
+		lists = append(lists, collector.opts.InfoProperties)
 	}
-
-	props := make([]zfs.DatasetProperty, 0, len(descs))
-	for prop := range descs {
-		props = append(props, prop)
+	var props []zfs.DatasetProperty
+	seen := make(map[zfs.DatasetProperty]bool)
+	for _, list := range lists {
+		for _, prop := range list {
+			if !seen[prop] && dataset.HasProperty(prop) {
+				seen[prop] = true
+				props = append(props, prop)
+			}
+		}
 	}
 
 	vals, err := dataset.Gets(props...)
@@ -156,26 +310,11 @@ func (collector *DatasetCollector) collectDataset(metrics chan<- prometheus.Metr
 		log.Printf("error reading dataset properties: %v", err)
 
 	} else {
-		for prop, val := range vals {
-			var value float64
-
-			switch v := val.(type) {
-			case *zfs.DatasetPropertyNumber:
-				value = float64(v.Value())
-			case *zfs.DatasetPropertyIndex:
-				value = float64(v.Value())
-
-			default:
-				collector.datasetErrors[name]++
-				log.Printf("unknown property type for '%s'", prop)
-				continue
-			}
-
-			metrics <- prometheus.MustNewConstMetric(
-				descs[prop], prometheus.GaugeValue,
-				value, name, pool, typ.String(), dsname,
-			)
+		collector.collectProperties(metrics, name, vals, labels)
+		if typ != zfs.DatasetTypeSnapshot {
+			collector.collectInfo(metrics, vals, labels)
 		}
+		collector.collectSources(metrics, vals, labels)
 	}
 
 	metrics <- prometheus.MustNewConstMetric(
@@ -185,3 +324,70 @@ func (collector *DatasetCollector) collectDataset(metrics chan<- prometheus.Metr
 		name,
 	)
 }
+
+func (collector *DatasetCollector) collectProperties(metrics chan<- prometheus.Metric, name string, vals map[zfs.DatasetProperty]zfs.DatasetPropertyValue, labels []string) {
+	for _, prop := range collector.opts.Properties {
+		val, ok := vals[prop]
+		if !ok {
+			continue
+		}
+
+		var raw uint64
+
+		switch v := val.(type) {
+		case *zfs.DatasetPropertyNumber:
+			raw = v.Value()
+		case *zfs.DatasetPropertyIndex:
+			raw = v.Value()
+
+		default:
+			collector.datasetErrors[name]++
+			log.Printf("unknown property type for '%s'", prop)
+			continue
+		}
+
+		value, ok := datasetPropertyMetrics[prop].value(raw)
+		if !ok {
+			continue
+		}
+
+		metrics <- prometheus.MustNewConstMetric(
+			collector.descs[prop], prometheus.GaugeValue,
+			value, labels...,
+		)
+	}
+}
+
+func (collector *DatasetCollector) collectInfo(metrics chan<- prometheus.Metric, vals map[zfs.DatasetProperty]zfs.DatasetPropertyValue, labels []string) {
+	if collector.info == nil {
+		return
+	}
+
+	labels = labels[:len(labels):len(labels)]
+	for _, prop := range collector.opts.InfoProperties {
+		var value string
+		if val, ok := vals[prop]; ok {
+			value = datasetPropertyValue(val)
+		}
+		labels = append(labels, value)
+	}
+
+	metrics <- prometheus.MustNewConstMetric(collector.info, prometheus.GaugeValue, 1, labels...)
+}
+
+func (collector *DatasetCollector) collectSources(metrics chan<- prometheus.Metric, vals map[zfs.DatasetProperty]zfs.DatasetPropertyValue, labels []string) {
+	for _, prop := range collector.opts.SourceProperties {
+		val, ok := vals[prop]
+		if !ok {
+			continue
+		}
+
+		metrics <- prometheus.MustNewConstMetric(
+			collector.source, prometheus.GaugeValue, 1,
+			append(labels[:len(labels):len(labels)],
+				prop.String(), datasetPropertyValue(val),
+				val.Source().String(), val.InheritedFrom(),
+			)...,
+		)
+	}
+}
@@ -0,0 +1,360 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+// datasetPropertyMetric describes how a numeric or boolean dataset property
+// is exported.
+type datasetPropertyMetric struct {
+	name string
+	help string
+
+	// unlimited marks properties that use UINT64_MAX to mean "none", which is
+	// left out rather than exported as an enormous number.
+	unlimited bool
+}
+
+// datasetPropertyMetrics covers every numeric property other than GUIDs, which
+// a float can't hold exactly, and every index property with an on/off or
+// numeric meaning. Index properties that are enumerations such as compression
+// or checksum aren't included.
+var datasetPropertyMetrics = map[zfs.DatasetProperty]datasetPropertyMetric{
+	// Space accounting
+	zfs.DatasetPropUsed: {
+		name: "zfs_dataset_used_bytes",
+		help: "space used by the dataset and all its descendents in bytes",
+	},
+	zfs.DatasetPropAvailable: {
+		name: "zfs_dataset_available_bytes",
+		help: "space available to the dataset and all its children in bytes",
+	},
+	zfs.DatasetPropReferenced: {
+		name: "zfs_dataset_referenced_bytes",
+		help: "space referenced by the dataset, which may be shared with other datasets, in bytes",
+	},
+	zfs.DatasetPropWritten: {
+		name: "zfs_dataset_written_bytes",
+		help: "space referenced by the dataset that was written since the previous snapshot in bytes",
+	},
+	zfs.DatasetPropUsedSnap: {
+		name: "zfs_dataset_used_by_snapshots_bytes",
+		help: "space used by snapshots of the dataset in bytes",
+	},
+	zfs.DatasetPropUsedDS: {
+		name: "zfs_dataset_used_by_dataset_bytes",
+		help: "space used by the dataset itself in bytes",
+	},
+	zfs.DatasetPropUsedChild: {
+		name: "zfs_dataset_used_by_children_bytes",
+		help: "space used by children of the dataset in bytes",
+	},
+	zfs.DatasetPropUsedRefReservation: {
+		name: "zfs_dataset_used_by_refreservation_bytes",
+		help: "space used by the refreservation of the dataset in bytes",
+	},
+	zfs.DatasetPropLogicalUsed: {
+		name: "zfs_dataset_logical_used_bytes",
+		help: "uncompressed space used by the dataset and all its descendents in bytes",
+	},
+	zfs.DatasetPropLogicalReferenced: {
+		name: "zfs_dataset_logical_referenced_bytes",
+		help: "uncompressed space referenced by the dataset in bytes",
+	},
+	// Ratios are exported as stored, multiplied by 100, for compatibility
+	zfs.DatasetPropCompressratio: {
+		name: "zfs_dataset_compress_ratio",
+		help: "compression ratio achieved for the space used by the dataset and its descendents, multiplied by 100",
+	},
+	zfs.DatasetPropRefRatio: {
+		name: "zfs_dataset_referenced_compress_ratio",
+		help: "compression ratio achieved for the space referenced by the dataset, multiplied by 100",
+	},
+
+	// Quotas and reservations; zero means none
+	zfs.DatasetPropQuota: {
+		name: "zfs_dataset_quota_bytes",
+		help: "limit on the space used by the dataset and its descendents in bytes, zero if unlimited",
+	},
+	zfs.DatasetPropRefQuota: {
+		name: "zfs_dataset_refquota_bytes",
+		help: "limit on the space referenced by the dataset in bytes, zero if unlimited",
+	},
+	zfs.DatasetPropReservation: {
+		name: "zfs_dataset_reservation_bytes",
+		help: "space guaranteed to the dataset and its descendents in bytes",
+	},
+	zfs.DatasetPropRefReservation: {
+		name: "zfs_dataset_refreservation_bytes",
+		help: "space guaranteed to the dataset itself in bytes",
+	},
+
+	// Filesystem and snapshot limits
+	zfs.DatasetPropFilesystemCount: {
+		name:      "zfs_dataset_filesystems",
+		help:      "number of filesystems below the dataset, only tracked when a limit is set",
+		unlimited: true,
+	},
+	zfs.DatasetPropFilesystemLimit: {
+		name:      "zfs_dataset_filesystems_limit",
+		help:      "limit on the number of filesystems below the dataset",
+		unlimited: true,
+	},
+	zfs.DatasetPropSnapshotCount: {
+		name:      "zfs_dataset_snapshots_limit_count",
+		help:      "number of snapshots of the dataset and its descendents counted against the snapshot limit, only tracked when a limit is set",
+		unlimited: true,
+	},
+	zfs.DatasetPropSnapshotLimit: {
+		name:      "zfs_dataset_snapshots_limit",
+		help:      "limit on the number of snapshots of the dataset and its descendents",
+		unlimited: true,
+	},
+
+	// Layout
+	zfs.DatasetPropRecordsize: {
+		name: "zfs_dataset_recordsize_bytes",
+		help: "suggested block size for files in the filesystem in bytes",
+	},
+	zfs.DatasetPropVolsize: {
+		name: "zfs_volume_size_bytes",
+		help: "size in bytes of a zfs volume",
+	},
+	zfs.DatasetPropVolblocksize: {
+		name: "zfs_volume_block_size_bytes",
+		help: "block size of a zfs volume in bytes",
+	},
+	zfs.DatasetPropSpecialSmallBlocks: {
+		name: "zfs_dataset_special_small_blocks_bytes",
+		help: "largest block size stored on the special allocation class in bytes, zero if disabled",
+	},
+	zfs.DatasetPropCopies: {
+		name: "zfs_dataset_copies",
+		help: "number of copies of data stored for the dataset",
+	},
+
+	// Identity and history
+	zfs.DatasetPropCreation: {
+		name: "zfs_dataset_created_timestamp_seconds",
+		help: "Unix timestamp representing the created date/time of the dataset",
+	},
+	zfs.DatasetPropCreateTXG: {
+		name: "zfs_dataset_created_txg",
+		help: "transaction group in which the dataset was created",
+	},
+	zfs.DatasetPropObjSetID: {
+		name: "zfs_dataset_objset_id",
+		help: "identifier of the object set of the dataset within the pool",
+	},
+	zfs.DatasetPropVersion: {
+		name: "zfs_dataset_version",
+		help: "on-disk version of the filesystem",
+	},
+	zfs.DatasetPropUserRefs: {
+		name: "zfs_dataset_user_holds",
+		help: "number of user holds on the snapshot",
+	},
+	zfs.DatasetPropPBKDF2Iters: {
+		name: "zfs_dataset_pbkdf2_iterations",
+		help: "number of PBKDF2 iterations used to derive the wrapping key from a passphrase",
+	},
+
+	// On/off properties
+	zfs.DatasetPropMounted: {
+		name: "zfs_dataset_mounted",
+		help: "whether the filesystem is mounted",
+	},
+	zfs.DatasetPropReadonly: {
+		name: "zfs_dataset_readonly",
+		help: "whether the dataset is read-only",
+	},
+	zfs.DatasetPropAtime: {
+		name: "zfs_dataset_atime",
+		help: "whether access times are updated on read",
+	},
+	zfs.DatasetPropRelAtime: {
+		name: "zfs_dataset_relatime",
+		help: "whether access times are only updated relative to modify or change times",
+	},
+	zfs.DatasetPropDevices: {
+		name: "zfs_dataset_devices",
+		help: "whether device nodes can be opened",
+	},
+	zfs.DatasetPropExec: {
+		name: "zfs_dataset_exec",
+		help: "whether programs can be executed",
+	},
+	zfs.DatasetPropSetuid: {
+		name: "zfs_dataset_setuid",
+		help: "whether the setuid bit is respected",
+	},
+	zfs.DatasetPropZoned: {
+		name: "zfs_dataset_zoned",
+		help: "whether the dataset is managed from a non-global zone or namespace",
+	},
+	zfs.DatasetPropVScan: {
+		name: "zfs_dataset_vscan",
+		help: "whether regular files are scanned for viruses",
+	},
+	zfs.DatasetPropNbmand: {
+		name: "zfs_dataset_nbmand",
+		help: "whether the filesystem is mounted with non-blocking mandatory locks",
+	},
+	zfs.DatasetPropOverlay: {
+		name: "zfs_dataset_overlay",
+		help: "whether the filesystem can be mounted over a non-empty directory",
+	},
+	zfs.DatasetPropUtf8Only: {
+		name: "zfs_dataset_utf8only",
+		help: "whether file names that aren't valid UTF-8 are rejected",
+	},
+	zfs.DatasetPropDeferDestroy: {
+		name: "zfs_dataset_defer_destroy",
+		help: "whether the snapshot is marked for deferred destroy",
+	},
+}
+
+// DefaultDatasetProperties are the dataset properties collected by default.
+var DefaultDatasetProperties = []string{
+	"creation", "used", "referenced", "written", "available",
+	"compressratio", "readonly", "quota", "volsize", "volblocksize",
+	"mounted",
+}
+
+// ParseDatasetProperties resolves property names, as used by `zfs get`, to
+// the dataset properties to collect. The name "all" selects every property
+// that can be exported.
+func ParseDatasetProperties(names []string) ([]zfs.DatasetProperty, error) {
+	for _, name := range names {
+		if strings.TrimSpace(name) == "all" {
+			props := make([]zfs.DatasetProperty, 0, len(datasetPropertyMetrics))
+			for prop := range datasetPropertyMetrics {
+				props = append(props, prop)
+			}
+			return props, nil
+		}
+	}
+
+	props, err := LookupDatasetProperties(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, prop := range props {
+		if _, ok := datasetPropertyMetrics[prop]; !ok {
+			return nil, fmt.Errorf("dataset property '%s' has no numeric value", prop)
+		}
+	}
+	return props, nil
+}
+
+// LookupDatasetProperties resolves property names, as used by `zfs get`, to
+// native dataset properties of any type. Empty names are ignored.
+func LookupDatasetProperties(names []string) ([]zfs.DatasetProperty, error) {
+	var props []zfs.DatasetProperty
+	seen := make(map[zfs.DatasetProperty]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prop, ok := zfs.DatasetPropertyByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown dataset property '%s'", name)
+		}
+		if !seen[prop] {
+			seen[prop] = true
+			props = append(props, prop)
+		}
+	}
+	return props, nil
+}
+
+// ParseDatasetInfoProperties resolves property names, as used by `zfs get`, to
+// the dataset properties exported as labels on zfs_dataset_properties_info.
+func ParseDatasetInfoProperties(names []string) ([]zfs.DatasetProperty, error) {
+	props, err := LookupDatasetProperties(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, prop := range props {
+		for _, label := range datasetLabels {
+			if prop.String() == label {
+				return nil, fmt.Errorf("dataset property '%s' clashes with the '%s' label of every dataset metric", prop, label)
+			}
+		}
+	}
+	return props, nil
+}
+
+// ParseUserPropertyLabels parses user-defined property names to add as labels
+// to dataset metrics. Each is either just the property name, such as
+// "acme:owner", which is labelled as "acme_owner", or "property=label" to name
+// the label explicitly. Labels can't clash with those of every dataset metric,
+// nor with each other; NewDatasetCollector checks the rest.
+func ParseUserPropertyLabels(names []string) ([]UserPropertyLabel, error) {
+	var props []UserPropertyLabel
+	seen := make(map[string]string)
+	for _, name := range datasetLabels {
+		seen[name] = "every dataset metric"
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prop, label, ok := strings.Cut(name, "=")
+		if !ok {
+			label = invalidLabelChars.ReplaceAllString(prop, "_")
+		}
+		if !strings.Contains(prop, ":") {
+			return nil, fmt.Errorf("'%s' isn't a user property, which must contain a ':'", prop)
+		}
+		if !model.LabelName(label).IsValid() {
+			return nil, fmt.Errorf("invalid label name '%s' for user property '%s'", label, prop)
+		}
+		if owner, ok := seen[label]; ok {
+			return nil, fmt.Errorf("label name '%s' for user property '%s' is already used by %s", label, prop, owner)
+		}
+		seen[label] = "user property '" + prop + "'"
+
+		props = append(props, UserPropertyLabel{Property: prop, Label: label})
+	}
+	return props, nil
+}
+
+var invalidLabelChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// datasetPropertyValue formats a property value of any type the way `zfs get
+// -p` does.
+func datasetPropertyValue(val zfs.DatasetPropertyValue) string {
+	switch v := val.(type) {
+	case *zfs.DatasetPropertyNumber:
+		return strconv.FormatUint(v.Value(), 10)
+	case *zfs.DatasetPropertyIndex:
+		return v.Name()
+	case *zfs.DatasetPropertyString:
+		return v.Value()
+	default:
+		return ""
+	}
+}
+
+// value converts a raw property value into the exported value, returning
+// false if it shouldn't be exported.
+func (m datasetPropertyMetric) value(raw uint64) (float64, bool) {
+	if m.unlimited && raw == math.MaxUint64 {
+		return 0, false
+	}
+	return float64(raw), true
+}
@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dbufMetrics = map[string]kstatMetric{
+		"cache_count": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_count",
+				"number of dbufs in the dbuf cache",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"cache_size_bytes": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_size_bytes",
+				"size of the dbuf cache in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"cache_size_bytes_max": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_size_max_bytes",
+				"largest size the dbuf cache has reached in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"cache_target_bytes": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_target_bytes",
+				"target size of the dbuf cache in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"cache_lowater_bytes": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_lowater_bytes",
+				"size of the dbuf cache below which eviction stops, in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"cache_hiwater_bytes": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_hiwater_bytes",
+				"size of the dbuf cache above which eviction is done synchronously, in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"cache_total_evicts": {
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_evicts_total",
+				"number of dbufs evicted from the dbuf cache",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+		"hash_hits": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_hits_total",
+				"number of dbuf hash table lookups that found a dbuf. Hit ratio is hits / (hits + misses)",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+		"hash_misses": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_misses_total",
+				"number of dbuf hash table lookups that didn't find a dbuf",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+		"hash_collisions": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_collisions_total",
+				"number of dbuf hash table collisions",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+		"hash_elements": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_elements",
+				"number of dbufs in the dbuf hash table",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"hash_elements_max": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_elements_max",
+				"largest number of dbufs the dbuf hash table has held",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"hash_chains": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_chains",
+				"number of dbuf hash table buckets holding more than one dbuf",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"hash_chain_max": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_chain_max",
+				"length of the longest dbuf hash table chain",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"hash_insert_race": {
+			prometheus.NewDesc(
+				"zfs_dbuf_hash_insert_race_total",
+				"number of times a dbuf was inserted into the hash table by another thread first",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+		"metadata_cache_count": {
+			prometheus.NewDesc(
+				"zfs_dbuf_metadata_cache_count",
+				"number of dbufs in the dbuf metadata cache",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"metadata_cache_size_bytes": {
+			prometheus.NewDesc(
+				"zfs_dbuf_metadata_cache_size_bytes",
+				"size of the dbuf metadata cache in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"metadata_cache_size_bytes_max": {
+			prometheus.NewDesc(
+				"zfs_dbuf_metadata_cache_size_max_bytes",
+				"largest size the dbuf metadata cache has reached in bytes",
+				nil, nil,
+			),
+			prometheus.GaugeValue,
+		},
+		"metadata_cache_overflow": {
+			prometheus.NewDesc(
+				"zfs_dbuf_metadata_cache_overflow_total",
+				"number of times the dbuf metadata cache was full when a dbuf was added",
+				nil, nil,
+			),
+			prometheus.CounterValue,
+		},
+	}
+
+	dbufCacheLevelCount = kstatIndexedMetric{
+		prefix: "cache_level_",
+		kstatMetric: kstatMetric{
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_level_count",
+				"number of dbufs in the dbuf cache by indirection level",
+				[]string{"level"}, nil,
+			),
+			prometheus.GaugeValue,
+		},
+	}
+
+	dbufCacheLevelBytes = kstatIndexedMetric{
+		prefix: "cache_level_",
+		suffix: "_bytes",
+		kstatMetric: kstatMetric{
+			prometheus.NewDesc(
+				"zfs_dbuf_cache_level_bytes",
+				"size of the dbuf cache in bytes by indirection level",
+				[]string{"level"}, nil,
+			),
+			prometheus.GaugeValue,
+		},
+	}
+)
+
+// NewDbufCollector exports the dbuf cache statistics from dbufstats.
+func NewDbufCollector(procfs string) *KstatCollector {
+	return newKstatCollector(procfs, "dbufstats", dbufMetrics,
+		dbufCacheLevelCount, dbufCacheLevelBytes,
+	)
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+// Values of the keystatus property, from zfs_keystatus_t
+const (
+	keyStatusNone = iota
+	keyStatusUnavailable
+	keyStatusAvailable
+)
+
+var poolKeysUnloaded = prometheus.NewDesc(
+	"zfs_pool_encrypted_datasets_key_unloaded",
+	"number of encrypted filesystems and volumes in the pool whose key isn't loaded",
+	[]string{"pool"}, nil,
+)
+
+type encryptionDescs struct {
+	info      *prometheus.Desc
+	keyLoaded *prometheus.Desc
+}
+
+func newEncryptionDescs(b *descBuilder) encryptionDescs {
+	return encryptionDescs{
+		info: b.desc(
+			"zfs_dataset_encryption_info",
+			"encryption of an encrypted dataset and the encryption root it inherits its key from",
+			"encryption", "encryption_root", "keyformat", "keylocation",
+		),
+		keyLoaded: b.desc(
+			"zfs_dataset_key_loaded",
+			"whether the key of an encrypted dataset is loaded, so it can be mounted or used",
+		),
+	}
+}
+
+func (descs encryptionDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.info
+	ch <- descs.keyLoaded
+	ch <- poolKeysUnloaded
+}
+
+// collectEncryption exports the encryption of a filesystem or volume, and
+// counts it per pool if its key isn't loaded.
+func (collector *DatasetCollector) collectEncryption(ch chan<- prometheus.Metric, dataset *zfs.Dataset, labels []string, unloaded map[string]int) {
+	name := dataset.Name()
+	pool := dataset.Pool().Name()
+	if _, ok := unloaded[pool]; !ok {
+		unloaded[pool] = 0
+	}
+
+	vals, err := dataset.Gets(zfs.DatasetPropKeyStatus, zfs.DatasetPropEncryption)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading encryption properties of '%s': %v", name, err)
+		return
+	}
+
+	status := vals[zfs.DatasetPropKeyStatus].(*zfs.DatasetPropertyIndex).Value()
+	if status == keyStatusNone {
+		// Not encrypted
+		return
+	}
+
+	// encryptionroot has no value, so can't be read, unless encrypted
+	keyVals, err := dataset.Gets(
+		zfs.DatasetPropEncryptionRoot,
+		zfs.DatasetPropKeyFormat,
+		zfs.DatasetPropKeyLocation,
+	)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading encryption properties of '%s': %v", name, err)
+		return
+	}
+	for prop, val := range keyVals {
+		vals[prop] = val
+	}
+
+	loaded := 0.0
+	if status == keyStatusAvailable {
+		loaded = 1.0
+	} else {
+		unloaded[pool]++
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		collector.encryption.info, prometheus.GaugeValue, 1,
+		append(labels[:len(labels):len(labels)],
+			datasetPropertyValue(vals[zfs.DatasetPropEncryption]),
+			datasetPropertyValue(vals[zfs.DatasetPropEncryptionRoot]),
+			datasetPropertyValue(vals[zfs.DatasetPropKeyFormat]),
+			datasetPropertyValue(vals[zfs.DatasetPropKeyLocation]),
+		)...,
+	)
+	ch <- prometheus.MustNewConstMetric(collector.encryption.keyLoaded, prometheus.GaugeValue, loaded, labels...)
+}
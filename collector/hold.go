@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"log"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+type holdDescs struct {
+	holds          *prometheus.Desc
+	createdAt      *prometheus.Desc
+	deferredPinned *prometheus.Desc
+}
+
+func newHoldDescs(b *descBuilder) holdDescs {
+	return holdDescs{
+		holds: b.desc(
+			"zfs_snapshot_holds",
+			"number of user holds on the snapshot, which prevent it from being destroyed; only snapshots with holds are exported",
+		),
+		createdAt: b.desc(
+			"zfs_snapshot_hold_created_timestamp_seconds",
+			"Unix timestamp of when the user hold with the tag was placed on the snapshot",
+			"tag",
+		),
+		deferredPinned: b.desc(
+			"zfs_snapshot_deferred_destroy_pinned",
+			"snapshot marked for deferred destroy that still exists, as it has holds or clones; only such snapshots are exported",
+		),
+	}
+}
+
+func (descs holdDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.holds
+	ch <- descs.createdAt
+	ch <- descs.deferredPinned
+}
+
+// collectSnapshotHolds exports the holds on a snapshot, and whether it is
+// pinned despite being destroyed. Snapshots without holds are skipped. Errors
+// are counted against the dataset the snapshot is of, like its clones.
+func (collector *DatasetCollector) collectSnapshotHolds(ch chan<- prometheus.Metric, snapshot *zfs.Dataset, labels []string) {
+	name := snapshot.Name()
+	parent := name[:strings.Index(name, "@")]
+
+	vals, err := snapshot.Gets(zfs.DatasetPropUserRefs, zfs.DatasetPropDeferDestroy)
+	if err != nil {
+		collector.datasetErrors[parent]++
+		log.Printf("error reading holds of '%s': %v", name, err)
+		return
+	}
+	userrefs := vals[zfs.DatasetPropUserRefs].(*zfs.DatasetPropertyNumber).Value()
+	deferred := vals[zfs.DatasetPropDeferDestroy].(*zfs.DatasetPropertyIndex).Value()
+	if userrefs == 0 && deferred == 0 {
+		return
+	}
+
+	// A snapshot is only destroyed once it is released, so still existing
+	// means it's pinned
+	if deferred != 0 {
+		ch <- prometheus.MustNewConstMetric(collector.holds.deferredPinned, prometheus.GaugeValue, 1, labels...)
+	}
+	if userrefs == 0 {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.holds.holds, prometheus.GaugeValue, float64(userrefs), labels...)
+
+	holds, err := snapshot.Holds()
+	if err != nil {
+		collector.datasetErrors[parent]++
+		log.Printf("error reading holds of '%s': %v", name, err)
+		return
+	}
+	for tag, created := range holds {
+		ch <- prometheus.MustNewConstMetric(
+			collector.holds.createdAt, prometheus.GaugeValue,
+			float64(created.Unix()),
+			append(labels[:len(labels):len(labels)], tag)...,
+		)
+	}
+}
@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+var (
+	importablePoolsDesc = prometheus.NewDesc(
+		"zfs_importable_pools",
+		"number of pools found on attached devices that are not imported, excluding destroyed pools",
+		nil, nil,
+	)
+
+	importablePoolInfoDesc = prometheus.NewDesc(
+		"zfs_importable_pool_info",
+		"pool found on attached devices that is not imported",
+		[]string{"pool", "guid", "state", "status", "comment"}, nil,
+	)
+
+	importablePoolMMPStateDesc = prometheus.NewDesc(
+		"zfs_importable_pool_mmp_state",
+		"Multihost activity check result of a pool that is not imported [0: active, 1: inactive, 2: nohostid].",
+		[]string{"pool", "guid", "state"}, nil,
+	)
+	importablePoolMMPTXGDesc = prometheus.NewDesc(
+		"zfs_importable_pool_mmp_txg",
+		"TXG of the last multihost write seen by the activity check of a pool that is not imported",
+		[]string{"pool", "guid"}, nil,
+	)
+	importablePoolMMPSeqDesc = prometheus.NewDesc(
+		"zfs_importable_pool_mmp_seq",
+		"Sequence number of the last multihost write seen by the activity check of a pool that is not imported",
+		[]string{"pool", "guid"}, nil,
+	)
+	importablePoolMMPHostDesc = prometheus.NewDesc(
+		"zfs_importable_pool_mmp_host_info",
+		"Host that last wrote to a pool that is not imported, as seen by the activity check",
+		[]string{"pool", "guid", "hostname", "hostid"}, nil,
+	)
+)
+
+// ImportCollector searches for pools that are available to be imported. This
+// reads the labels of every device in the search path so can be slow.
+type ImportCollector struct {
+	libzfs *zfs.LibZFS
+	dirs   []string
+}
+
+// Describe implements prometheus.Collector.
+func (collector *ImportCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- importablePoolsDesc
+	descs <- importablePoolInfoDesc
+	descs <- importablePoolMMPStateDesc
+	descs <- importablePoolMMPTXGDesc
+	descs <- importablePoolMMPSeqDesc
+	descs <- importablePoolMMPHostDesc
+}
+
+func NewImportCollector(libzfs *zfs.LibZFS, dirs []string) *ImportCollector {
+	return &ImportCollector{
+		libzfs: libzfs,
+		dirs:   dirs,
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (collector *ImportCollector) Collect(ch chan<- prometheus.Metric) {
+	pools, err := collector.libzfs.SearchImport(collector.dirs)
+	if err != nil {
+		log.Printf("error searching for importable pools: %v", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		importablePoolsDesc,
+		prometheus.GaugeValue,
+		float64(len(pools)),
+	)
+
+	for _, pool := range pools {
+		guid := strconv.FormatUint(pool.GUID, 10)
+		ch <- prometheus.MustNewConstMetric(
+			importablePoolInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			pool.Name,
+			guid,
+			strings.ToLower(pool.State.String()),
+			strings.ToLower(pool.Status.String()),
+			pool.Comment,
+		)
+		if pool.MMP != nil {
+			collectImportMMP(ch, pool.Name, guid, pool.MMP)
+		}
+		pool.Close()
+	}
+}
+
+func collectImportMMP(ch chan<- prometheus.Metric, name, guid string, mmp *zfs.MMPInfo) {
+	ch <- prometheus.MustNewConstMetric(
+		importablePoolMMPStateDesc,
+		prometheus.GaugeValue,
+		float64(mmp.State),
+		name, guid, mmp.State.String(),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		importablePoolMMPTXGDesc,
+		prometheus.GaugeValue,
+		float64(mmp.TXG),
+		name, guid,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		importablePoolMMPSeqDesc,
+		prometheus.GaugeValue,
+		float64(mmp.Seq),
+		name, guid,
+	)
+	if mmp.Hostname != "" || mmp.HostID != 0 {
+		ch <- prometheus.MustNewConstMetric(
+			importablePoolMMPHostDesc,
+			prometheus.GaugeValue,
+			1,
+			name, guid, mmp.Hostname, fmt.Sprintf("%08x", mmp.HostID),
+		)
+	}
+}
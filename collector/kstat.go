@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// kstat data types, from include/sys/kstat.h
+const (
+	kstatDataChar = iota
+	kstatDataInt32
+	kstatDataUint32
+	kstatDataInt64
+	kstatDataUint64
+	kstatDataLong
+	kstatDataUlong
+	kstatDataString
+)
+
+// readKstat parses a KSTAT_TYPE_NAMED kstat file, as found under
+// /proc/spl/kstat/zfs, returning all numeric values keyed by name.
+func readKstat(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	/*
+		The first line is the kstat header and the second names the columns:
+
+		13 1 0x01 8 2176 11283430474 2162532410290391
+		name                            type data
+		hits                            4    191475
+	*/
+	stats := make(map[string]float64)
+	rd := bufio.NewScanner(file)
+	for line := 0; rd.Scan(); line++ {
+		if line < 2 {
+			continue
+		}
+
+		fields := strings.Fields(rd.Text())
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed kstat line %d in '%s'", line+1, path)
+		}
+
+		typ, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed kstat type for '%s': %w", fields[0], err)
+		}
+
+		var value float64
+		switch typ {
+		case kstatDataInt32, kstatDataInt64, kstatDataLong:
+			v, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed kstat value for '%s': %w", fields[0], err)
+			}
+			value = float64(v)
+		case kstatDataUint32, kstatDataUint64, kstatDataUlong:
+			v, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed kstat value for '%s': %w", fields[0], err)
+			}
+			value = float64(v)
+		default:
+			// Not a number
+			continue
+		}
+
+		stats[fields[0]] = value
+	}
+
+	return stats, rd.Err()
+}
+
+type kstatMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+// kstatIndexedMetric matches a family of kstat fields that only differ by an
+// index, such as "cache_level_0" through "cache_level_N", and exports them as a
+// single metric labelled with that index.
+type kstatIndexedMetric struct {
+	prefix string
+	suffix string
+	kstatMetric
+}
+
+func (m kstatIndexedMetric) match(name string) (string, bool) {
+	index, ok := strings.CutPrefix(name, m.prefix)
+	if !ok {
+		return "", false
+	}
+	index, ok = strings.CutSuffix(index, m.suffix)
+	if !ok {
+		return "", false
+	}
+	if _, err := strconv.Atoi(index); err != nil {
+		return "", false
+	}
+	return index, true
+}
+
+// KstatCollector exports the values of a single ZFS kstat, like
+// /proc/spl/kstat/zfs/dbufstats, using a fixed table of metrics. Fields that
+// aren't in the table are ignored, as are table entries missing from the kstat
+// as they vary between ZFS versions.
+type KstatCollector struct {
+	path    string
+	metrics map[string]kstatMetric
+	indexed []kstatIndexedMetric
+}
+
+func newKstatCollector(procfs, name string, metrics map[string]kstatMetric, indexed ...kstatIndexedMetric) *KstatCollector {
+	return &KstatCollector{
+		path:    filepath.Join(procfs, "spl/kstat/zfs", name),
+		metrics: metrics,
+		indexed: indexed,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (collector *KstatCollector) Describe(descs chan<- *prometheus.Desc) {
+	for _, metric := range collector.metrics {
+		descs <- metric.desc
+	}
+	for _, metric := range collector.indexed {
+		descs <- metric.desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (collector *KstatCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := readKstat(collector.path)
+	if err != nil {
+		log.Printf("error reading kstat: %v", err)
+		return
+	}
+
+	for name, value := range stats {
+		if metric, ok := collector.metrics[name]; ok {
+			ch <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, value)
+			continue
+		}
+
+		for _, metric := range collector.indexed {
+			if index, ok := metric.match(name); ok {
+				ch <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, value, index)
+				break
+			}
+		}
+	}
+}
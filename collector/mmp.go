@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type mmpWrite struct {
+	txg       uint64
+	timestamp uint64
+}
+
+// lastMMPWrite reads the most recent multihost write from the pool's MMP
+// history kstat. History is only recorded when the zfs_multihost_history
+// module parameter is non-zero, otherwise os.ErrNotExist is returned.
+func lastMMPWrite(procfs, pool string) (mmpWrite, error) {
+	path := filepath.Join(procfs, "spl/kstat/zfs", pool, "multihost")
+	file, err := os.Open(path)
+	if err != nil {
+		return mmpWrite{}, err
+	}
+	defer file.Close()
+
+	/*
+		id         txg        timestamp  error  duration   mmp_delay    vdev_guid                vdev_label vdev_path
+		2021       31469      1637170541      0     290440    125000000 8237648372938472636      2          /dev/sda1
+	*/
+	var last []string
+	header := false
+	rd := bufio.NewScanner(file)
+	for rd.Scan() {
+		fields := strings.Fields(rd.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "id" {
+			header = true
+			continue
+		}
+		if header {
+			last = fields
+		}
+	}
+	if err := rd.Err(); err != nil {
+		return mmpWrite{}, err
+	}
+	if last == nil {
+		return mmpWrite{}, os.ErrNotExist
+	}
+	if len(last) < 3 {
+		return mmpWrite{}, fmt.Errorf("malformed multihost history line in '%s'", path)
+	}
+
+	txg, err1 := strconv.ParseUint(last[1], 10, 64)
+	ts, err2 := strconv.ParseUint(last[2], 10, 64)
+	if err := errors.Join(err1, err2); err != nil {
+		return mmpWrite{}, fmt.Errorf("malformed multihost history in '%s': %w", path, err)
+	}
+
+	return mmpWrite{txg: txg, timestamp: ts}, nil
+}
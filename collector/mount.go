@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+type mountDescs struct {
+	info     *prometheus.Desc
+	expected *prometheus.Desc
+	missing  *prometheus.Desc
+	shadowed *prometheus.Desc
+}
+
+func newMountDescs(b *descBuilder) mountDescs {
+	return mountDescs{
+		info: b.desc(
+			"zfs_dataset_mount_info",
+			"mountpoint and canmount properties of a filesystem",
+			"mountpoint", "canmount",
+		),
+		expected: b.desc(
+			"zfs_dataset_mount_expected",
+			"whether the filesystem should be mounted automatically, as canmount=on and it has a mountpoint",
+		),
+		missing: b.desc(
+			"zfs_dataset_mount_missing",
+			"whether the filesystem should be mounted automatically but isn't in the mount table",
+		),
+		shadowed: b.desc(
+			"zfs_dataset_mount_shadowed",
+			"whether the filesystem is mounted but hidden by another filesystem mounted over the same path",
+		),
+	}
+}
+
+func (descs mountDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.info
+	ch <- descs.expected
+	ch <- descs.missing
+	ch <- descs.shadowed
+}
+
+// mountTable is the parts of /proc/self/mountinfo needed to check where zfs
+// filesystems are mounted.
+type mountTable struct {
+	// mountpoints of each zfs filesystem, keyed by dataset name
+	mountpoints map[string][]string
+	// top is the source of the most recent mount over each path, which hides
+	// any mounted before it
+	top map[string]string
+}
+
+// readMountinfo parses a mountinfo file as described in proc(5).
+func readMountinfo(path string) (*mountTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	/*
+		Optional fields before the "-" separator vary in number:
+
+		36 35 98:0 / /mnt/data rw,noatime master:1 - zfs tank/data rw,xattr
+	*/
+	table := &mountTable{
+		mountpoints: make(map[string][]string),
+		top:         make(map[string]string),
+	}
+	rd := bufio.NewScanner(file)
+	for line := 1; rd.Scan(); line++ {
+		fields := strings.Fields(rd.Text())
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || len(fields) < sep+3 {
+			return nil, fmt.Errorf("malformed mountinfo line %d in '%s'", line, path)
+		}
+
+		mountpoint := unescapeMountinfo(fields[4])
+		fstype := fields[sep+1]
+		source := unescapeMountinfo(fields[sep+2])
+
+		table.top[mountpoint] = source
+		if fstype == "zfs" {
+			table.mountpoints[source] = append(table.mountpoints[source], mountpoint)
+		}
+	}
+
+	return table, rd.Err()
+}
+
+// unescapeMountinfo decodes the octal escapes used for whitespace and
+// backslashes in mountinfo, such as "\040" for a space.
+func unescapeMountinfo(str string) string {
+	if !strings.Contains(str, `\`) {
+		return str
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(str); i++ {
+		if str[i] == '\\' && i+4 <= len(str) {
+			if b, err := strconv.ParseUint(str[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(b))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(str[i])
+	}
+	return sb.String()
+}
+
+// collectMount exports the mount properties of a filesystem, and checks them
+// against the mount table if it could be read.
+func (collector *DatasetCollector) collectMount(ch chan<- prometheus.Metric, dataset *zfs.Dataset, labels []string, mounts *mountTable) {
+	name := dataset.Name()
+
+	vals, err := dataset.Gets(zfs.DatasetPropMountpoint, zfs.DatasetPropCanMount)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading mount properties of '%s': %v", name, err)
+		return
+	}
+	mountpoint := datasetPropertyValue(vals[zfs.DatasetPropMountpoint])
+	canmount := datasetPropertyValue(vals[zfs.DatasetPropCanMount])
+
+	ch <- prometheus.MustNewConstMetric(
+		collector.mount.info, prometheus.GaugeValue, 1,
+		append(labels[:len(labels):len(labels)], mountpoint, canmount)...,
+	)
+
+	expected := 0.0
+	if canmount == "on" && strings.HasPrefix(mountpoint, "/") {
+		expected = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(collector.mount.expected, prometheus.GaugeValue, expected, labels...)
+
+	if mounts == nil {
+		return
+	}
+
+	missing := 0.0
+	if expected == 1.0 && len(mounts.mountpoints[name]) == 0 {
+		missing = 1.0
+	}
+	shadowed := 0.0
+	for _, path := range mounts.mountpoints[name] {
+		if mounts.top[path] != name {
+			shadowed = 1.0
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(collector.mount.missing, prometheus.GaugeValue, missing, labels...)
+	ch <- prometheus.MustNewConstMetric(collector.mount.shadowed, prometheus.GaugeValue, shadowed, labels...)
+}
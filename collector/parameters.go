@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var moduleParameterDesc = prometheus.NewDesc(
+	"zfs_module_parameter",
+	"value of a numeric zfs kernel module parameter (tunable)",
+	[]string{"name"}, nil,
+)
+
+type ModuleParameterCollector struct {
+	path string
+
+	// include and exclude filter parameters by name. A nil include matches
+	// everything and a nil exclude matches nothing.
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// Describe implements prometheus.Collector.
+func (collector *ModuleParameterCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- moduleParameterDesc
+}
+
+func NewModuleParameterCollector(sysfs string, include, exclude *regexp.Regexp) *ModuleParameterCollector {
+	return &ModuleParameterCollector{
+		path:    filepath.Join(sysfs, "module/zfs/parameters"),
+		include: include,
+		exclude: exclude,
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (collector *ModuleParameterCollector) Collect(ch chan<- prometheus.Metric) {
+	entries, err := os.ReadDir(collector.path)
+	if err != nil {
+		log.Printf("error reading module parameters: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if collector.include != nil && !collector.include.MatchString(name) {
+			continue
+		}
+		if collector.exclude != nil && collector.exclude.MatchString(name) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(collector.path, name))
+		if err != nil {
+			log.Printf("error reading module parameter '%s': %v", name, err)
+			continue
+		}
+
+		value, ok := parseParameter(strings.TrimSpace(string(data)))
+		if !ok {
+			// String-valued parameters can't be represented as a metric
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			moduleParameterDesc,
+			prometheus.GaugeValue,
+			value, name,
+		)
+	}
+}
+
+func parseParameter(str string) (float64, bool) {
+	// Parameters are all integers; ParseFloat would also accept strings such
+	// as "inf" or "nan"
+	if u, err := strconv.ParseUint(str, 10, 64); err == nil {
+		return float64(u), true
+	}
+	if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return float64(i), true
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+type receiveDescs struct {
+	pending   *prometheus.Desc
+	info      *prometheus.Desc
+	received  *prometheus.Desc
+	startTXG  *prometheus.Desc
+	startTime *prometheus.Desc
+}
+
+func newReceiveDescs(b *descBuilder) receiveDescs {
+	return receiveDescs{
+		pending: b.desc(
+			"zfs_dataset_receive_resume_pending",
+			"whether an interrupted resumable receive into the dataset is pending, keeping its partial state allocated",
+		),
+		info: b.desc(
+			"zfs_dataset_receive_resume_info",
+			"snapshot being received by a pending resumable receive, as named and identified on the sending side",
+			"snapshot", "guid", "incremental",
+		),
+		received: b.desc(
+			"zfs_dataset_receive_resume_received_bytes",
+			"bytes of the stream received before a pending resumable receive was interrupted",
+		),
+		startTXG: b.desc(
+			"zfs_dataset_receive_resume_started_txg",
+			"transaction group in which a pending resumable receive started",
+		),
+		startTime: b.desc(
+			"zfs_dataset_receive_resume_started_timestamp_seconds",
+			"Unix timestamp of when a pending resumable receive started",
+		),
+	}
+}
+
+func (descs receiveDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.pending
+	ch <- descs.info
+	ch <- descs.received
+	ch <- descs.startTXG
+	ch <- descs.startTime
+}
+
+// collectReceive exports whether a filesystem or volume has a pending
+// resumable receive and, if so, what it's receiving and when it started.
+func (collector *DatasetCollector) collectReceive(ch chan<- prometheus.Metric, dataset *zfs.Dataset, labels []string) {
+	name := dataset.Name()
+
+	token, err := dataset.ReceiveResumeToken()
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading receive resume token of dataset '%s': %v", name, err)
+		return
+	}
+	pending := 0.0
+	if token != "" {
+		pending = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(collector.receive.pending, prometheus.GaugeValue, pending, labels...)
+	if token == "" {
+		return
+	}
+
+	rt, err := collector.libzfs.DecodeResumeToken(token)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error decoding receive resume token of '%s': %v", name, err)
+	} else {
+		incremental := "false"
+		if rt.FromGUID != 0 {
+			incremental = "true"
+		}
+		ch <- prometheus.MustNewConstMetric(
+			collector.receive.info, prometheus.GaugeValue, 1,
+			append(labels[:len(labels):len(labels)],
+				rt.ToName, strconv.FormatUint(rt.ToGUID, 10), incremental,
+			)...,
+		)
+		ch <- prometheus.MustNewConstMetric(collector.receive.received, prometheus.GaugeValue, float64(rt.Bytes), labels...)
+	}
+
+	// The token doesn't say when the receive started, but an incremental
+	// receive into an existing dataset is made in a hidden %recv clone of it,
+	// and a full receive into the dataset itself, both created when it began
+	partial := dataset
+	if recv, err := collector.libzfs.DatasetOpen(name + "/%recv"); err == nil {
+		defer recv.Close()
+		partial = recv
+	}
+	vals, err := partial.Gets(zfs.DatasetPropCreateTXG, zfs.DatasetPropCreation)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading start of receive into '%s': %v", name, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		collector.receive.startTXG, prometheus.GaugeValue,
+		float64(vals[zfs.DatasetPropCreateTXG].(*zfs.DatasetPropertyNumber).Value()),
+		labels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		collector.receive.startTime, prometheus.GaugeValue,
+		float64(vals[zfs.DatasetPropCreation].(*zfs.DatasetPropertyNumber).Value()),
+		labels...,
+	)
+}
@@ -0,0 +1,225 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+// snapshotSummary aggregates the snapshots of a single dataset, so they can
+// be exported without a series set for each snapshot.
+type snapshotSummary struct {
+	count  int
+	used   uint64
+	oldest uint64
+	newest uint64
+
+	// policies summarise the matching snapshots of each of the collector's
+	// SnapshotPolicies, in the same order
+	policies []snapshotPolicySummary
+}
+
+type snapshotPolicySummary struct {
+	count  int
+	newest uint64
+}
+
+// SnapshotPolicy is a retention policy that the snapshots of each dataset
+// with names matching Datasets are checked against, such as those taken hourly
+// by a snapshot tool.
+type SnapshotPolicy struct {
+	Name string
+	// Datasets matches the names of the filesystems and volumes subject to
+	// the policy, which are checked even if they have no snapshots
+	Datasets *regexp.Regexp
+	// Pattern matches the snapshot name, after the '@'
+	Pattern *regexp.Regexp
+	// MaxAge is the maximum age of the newest snapshot, or zero for no limit
+	MaxAge time.Duration
+	// MinCount is the minimum number of snapshots kept
+	MinCount int
+}
+
+// ParseSnapshotPolicy parses a policy in the form
+// "name:datasets:pattern:max-age:min-count", such as
+// "hourly:^tank/home:_hourly$:2h:24". The datasets regexp can't contain a
+// colon, but can match one with \x3a.
+func ParseSnapshotPolicy(str string) (SnapshotPolicy, error) {
+	// The pattern may itself contain colons
+	name, rest, ok := strings.Cut(str, ":")
+	datasets, rest, ok2 := strings.Cut(rest, ":")
+	fields := strings.Split(rest, ":")
+	if !ok || !ok2 || name == "" || len(fields) < 3 {
+		return SnapshotPolicy{}, fmt.Errorf("snapshot policy '%s' isn't in the form name:datasets:pattern:max-age:min-count", str)
+	}
+	pattern := strings.Join(fields[:len(fields)-2], ":")
+	maxAge, minCount := fields[len(fields)-2], fields[len(fields)-1]
+
+	policy := SnapshotPolicy{Name: name}
+	var err error
+	if policy.Datasets, err = regexp.Compile(datasets); err != nil {
+		return SnapshotPolicy{}, fmt.Errorf("invalid datasets in snapshot policy '%s': %w", name, err)
+	}
+	if policy.Pattern, err = regexp.Compile(pattern); err != nil {
+		return SnapshotPolicy{}, fmt.Errorf("invalid pattern in snapshot policy '%s': %w", name, err)
+	}
+	if policy.MaxAge, err = time.ParseDuration(maxAge); err != nil {
+		return SnapshotPolicy{}, fmt.Errorf("invalid max age in snapshot policy '%s': %w", name, err)
+	}
+	if policy.MinCount, err = strconv.Atoi(minCount); err != nil {
+		return SnapshotPolicy{}, fmt.Errorf("invalid min count in snapshot policy '%s': %w", name, err)
+	}
+	return policy, nil
+}
+
+// String formats the policy in the form accepted by ParseSnapshotPolicy.
+func (policy SnapshotPolicy) String() string {
+	return fmt.Sprintf("%s:%s:%s:%s:%d", policy.Name, policy.Datasets, policy.Pattern, policy.MaxAge, policy.MinCount)
+}
+
+type snapshotSummaryDescs struct {
+	count  *prometheus.Desc
+	used   *prometheus.Desc
+	oldest *prometheus.Desc
+	newest *prometheus.Desc
+
+	policyCount     *prometheus.Desc
+	policyAge       *prometheus.Desc
+	policyCompliant *prometheus.Desc
+}
+
+func newSnapshotSummaryDescs(b *descBuilder) snapshotSummaryDescs {
+	return snapshotSummaryDescs{
+		count: b.desc(
+			"zfs_dataset_snapshots_count",
+			"number of snapshots of the dataset, excluding its descendents",
+		),
+		used: b.desc(
+			"zfs_dataset_snapshots_used_bytes",
+			"sum of the space used by each snapshot of the dataset in bytes, which excludes space shared between snapshots",
+		),
+		oldest: b.desc(
+			"zfs_dataset_snapshots_oldest_timestamp_seconds",
+			"Unix timestamp of the creation of the oldest snapshot of the dataset",
+		),
+		newest: b.desc(
+			"zfs_dataset_snapshots_newest_timestamp_seconds",
+			"Unix timestamp of the creation of the newest snapshot of the dataset",
+		),
+
+		policyCount: b.desc(
+			"zfs_dataset_snapshot_policy_count",
+			"number of snapshots of the dataset matching the snapshot policy",
+			"policy",
+		),
+		policyAge: b.desc(
+			"zfs_dataset_snapshot_policy_latest_age_seconds",
+			"age of the newest snapshot of the dataset matching the snapshot policy in seconds",
+			"policy",
+		),
+		policyCompliant: b.desc(
+			"zfs_dataset_snapshot_policy_compliant",
+			"whether the snapshots of the dataset matching the snapshot policy are new enough and numerous enough",
+			"policy",
+		),
+	}
+}
+
+func (descs snapshotSummaryDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- descs.count
+	ch <- descs.used
+	ch <- descs.oldest
+	ch <- descs.newest
+	ch <- descs.policyCount
+	ch <- descs.policyAge
+	ch <- descs.policyCompliant
+}
+
+// summariseSnapshot adds a snapshot to the summary of its dataset.
+func (collector *DatasetCollector) summariseSnapshot(summaries map[string]*snapshotSummary, snapshot *zfs.Dataset) {
+	name := snapshot.Name()
+	vals, err := snapshot.Gets(zfs.DatasetPropUsed, zfs.DatasetPropCreation)
+	if err != nil {
+		log.Printf("error reading snapshot properties of '%s': %v", name, err)
+		return
+	}
+	used := vals[zfs.DatasetPropUsed].(*zfs.DatasetPropertyNumber).Value()
+	created := vals[zfs.DatasetPropCreation].(*zfs.DatasetPropertyNumber).Value()
+
+	parent, short, _ := strings.Cut(name, "@")
+	summary, ok := summaries[parent]
+	if !ok {
+		summary = &snapshotSummary{
+			oldest:   created,
+			newest:   created,
+			policies: make([]snapshotPolicySummary, len(collector.opts.SnapshotPolicies)),
+		}
+		summaries[parent] = summary
+	}
+	summary.count++
+	summary.used += used
+	summary.oldest = min(summary.oldest, created)
+	summary.newest = max(summary.newest, created)
+
+	for i, policy := range collector.opts.SnapshotPolicies {
+		if policy.Pattern.MatchString(short) {
+			summary.policies[i].count++
+			summary.policies[i].newest = max(summary.policies[i].newest, created)
+		}
+	}
+}
+
+func (collector *DatasetCollector) collectSnapshotSummary(ch chan<- prometheus.Metric, summary *snapshotSummary, labels []string) {
+	if summary == nil {
+		ch <- prometheus.MustNewConstMetric(collector.snapshots.count, prometheus.GaugeValue, 0, labels...)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.snapshots.count, prometheus.GaugeValue, float64(summary.count), labels...)
+	ch <- prometheus.MustNewConstMetric(collector.snapshots.used, prometheus.GaugeValue, float64(summary.used), labels...)
+	ch <- prometheus.MustNewConstMetric(collector.snapshots.oldest, prometheus.GaugeValue, float64(summary.oldest), labels...)
+	ch <- prometheus.MustNewConstMetric(collector.snapshots.newest, prometheus.GaugeValue, float64(summary.newest), labels...)
+}
+
+// collectSnapshotPolicies checks the snapshots of a dataset against each
+// policy that selects it. A dataset without any snapshots matching a policy
+// has a count of zero, and isn't compliant unless the policy requires none.
+func (collector *DatasetCollector) collectSnapshotPolicies(ch chan<- prometheus.Metric, name string, summary *snapshotSummary, labels []string, now time.Time) {
+	for i, policy := range collector.opts.SnapshotPolicies {
+		if !policy.Datasets.MatchString(name) {
+			continue
+		}
+		var matched snapshotPolicySummary
+		if summary != nil {
+			matched = summary.policies[i]
+		}
+		policyLabels := append(labels[:len(labels):len(labels)], policy.Name)
+
+		if matched.count == 0 {
+			compliant := 0.0
+			if policy.MinCount <= 0 && policy.MaxAge <= 0 {
+				compliant = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(collector.snapshots.policyCount, prometheus.GaugeValue, 0, policyLabels...)
+			ch <- prometheus.MustNewConstMetric(collector.snapshots.policyCompliant, prometheus.GaugeValue, compliant, policyLabels...)
+			continue
+		}
+
+		age := now.Sub(time.Unix(int64(matched.newest), 0))
+		compliant := 1.0
+		if matched.count < policy.MinCount || (policy.MaxAge > 0 && age > policy.MaxAge) {
+			compliant = 0.0
+		}
+
+		ch <- prometheus.MustNewConstMetric(collector.snapshots.policyCount, prometheus.GaugeValue, float64(matched.count), policyLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.snapshots.policyAge, prometheus.GaugeValue, age.Seconds(), policyLabels...)
+		ch <- prometheus.MustNewConstMetric(collector.snapshots.policyCompliant, prometheus.GaugeValue, compliant, policyLabels...)
+	}
+}
@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lastSyncedTXG reads the most recently committed TXG from the pool's TXG
+// history kstat. History is only recorded when the zfs_txg_history module
+// parameter is non-zero, otherwise os.ErrNotExist is returned.
+func lastSyncedTXG(procfs, pool string) (uint64, error) {
+	path := filepath.Join(procfs, "spl/kstat/zfs", pool, "txgs")
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	/*
+		txg      birth            state ndirty       nread        nwritten     reads    writes   otime        qtime        wtime        stime
+		12387    2147416934561    C     13205504     0            21180416     0        347      5003926400   20864        18176        59683840
+		12388    2152420860961    O     0            0            0            0        0        0            0            0            0
+	*/
+	var txg uint64
+	found := false
+	header := false
+	rd := bufio.NewScanner(file)
+	for rd.Scan() {
+		fields := strings.Fields(rd.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		if fields[0] == "txg" {
+			header = true
+			continue
+		}
+		// Only committed TXGs have been synced to disk
+		if !header || fields[2] != "C" {
+			continue
+		}
+
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("malformed txg history in '%s': %w", path, err)
+		}
+		if n > txg {
+			txg = n
+		}
+		found = true
+	}
+	if err := rd.Err(); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, os.ErrNotExist
+	}
+
+	return txg, nil
+}
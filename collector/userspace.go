@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+var (
+	userSpaceUserLabels    = []string{"dataset", "pool", "uid", "user"}
+	userSpaceGroupLabels   = []string{"dataset", "pool", "gid", "group"}
+	userSpaceProjectLabels = []string{"dataset", "pool", "projid"}
+
+	userSpaceDescs = map[zfs.UserSpaceType]*prometheus.Desc{
+		zfs.UserSpaceUserUsed: prometheus.NewDesc(
+			"zfs_dataset_user_used_bytes",
+			"space used by each user in the filesystem in bytes",
+			userSpaceUserLabels, nil,
+		),
+		zfs.UserSpaceUserQuota: prometheus.NewDesc(
+			"zfs_dataset_user_quota_bytes",
+			"limit on the space used by each user in the filesystem in bytes",
+			userSpaceUserLabels, nil,
+		),
+		zfs.UserSpaceUserObjUsed: prometheus.NewDesc(
+			"zfs_dataset_user_used_objects",
+			"number of objects (files and directories) owned by each user in the filesystem",
+			userSpaceUserLabels, nil,
+		),
+		zfs.UserSpaceUserObjQuota: prometheus.NewDesc(
+			"zfs_dataset_user_quota_objects",
+			"limit on the number of objects owned by each user in the filesystem",
+			userSpaceUserLabels, nil,
+		),
+		zfs.UserSpaceGroupUsed: prometheus.NewDesc(
+			"zfs_dataset_group_used_bytes",
+			"space used by each group in the filesystem in bytes",
+			userSpaceGroupLabels, nil,
+		),
+		zfs.UserSpaceGroupQuota: prometheus.NewDesc(
+			"zfs_dataset_group_quota_bytes",
+			"limit on the space used by each group in the filesystem in bytes",
+			userSpaceGroupLabels, nil,
+		),
+		zfs.UserSpaceGroupObjUsed: prometheus.NewDesc(
+			"zfs_dataset_group_used_objects",
+			"number of objects (files and directories) owned by each group in the filesystem",
+			userSpaceGroupLabels, nil,
+		),
+		zfs.UserSpaceGroupObjQuota: prometheus.NewDesc(
+			"zfs_dataset_group_quota_objects",
+			"limit on the number of objects owned by each group in the filesystem",
+			userSpaceGroupLabels, nil,
+		),
+		zfs.UserSpaceProjectUsed: prometheus.NewDesc(
+			"zfs_dataset_project_used_bytes",
+			"space used by each project in the filesystem in bytes",
+			userSpaceProjectLabels, nil,
+		),
+		zfs.UserSpaceProjectQuota: prometheus.NewDesc(
+			"zfs_dataset_project_quota_bytes",
+			"limit on the space used by each project in the filesystem in bytes",
+			userSpaceProjectLabels, nil,
+		),
+		zfs.UserSpaceProjectObjUsed: prometheus.NewDesc(
+			"zfs_dataset_project_used_objects",
+			"number of objects (files and directories) in each project in the filesystem",
+			userSpaceProjectLabels, nil,
+		),
+		zfs.UserSpaceProjectObjQuota: prometheus.NewDesc(
+			"zfs_dataset_project_quota_objects",
+			"limit on the number of objects in each project in the filesystem",
+			userSpaceProjectLabels, nil,
+		),
+	}
+)
+
+// UserSpaceCollector exports per-user, per-group and per-project space
+// accounting of every filesystem, like `zfs userspace` and `zfs groupspace`.
+type UserSpaceCollector struct {
+	libzfs *zfs.LibZFS
+
+	// passwd and group are files in the format of /etc/passwd and /etc/group
+	// used to name IDs. IDs are left unnamed if empty.
+	passwd string
+	group  string
+}
+
+// Describe implements prometheus.Collector.
+func (collector *UserSpaceCollector) Describe(descs chan<- *prometheus.Desc) {
+	for _, desc := range userSpaceDescs {
+		descs <- desc
+	}
+}
+
+func NewUserSpaceCollector(libzfs *zfs.LibZFS, passwd, group string) *UserSpaceCollector {
+	return &UserSpaceCollector{
+		libzfs: libzfs,
+		passwd: passwd,
+		group:  group,
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (collector *UserSpaceCollector) Collect(ch chan<- prometheus.Metric) {
+	users := readIDNames(collector.passwd)
+	groups := readIDNames(collector.group)
+
+	datasets, err := collector.libzfs.DatasetOpenAll(zfs.DatasetTypeFilesystem, -1)
+	if err != nil {
+		log.Printf("error opening datasets: %v", err)
+		return
+	}
+
+	for _, dataset := range datasets {
+		collector.collectDataset(ch, dataset, users, groups)
+		dataset.Close()
+	}
+
+	runtime.GC()
+}
+
+func (collector *UserSpaceCollector) collectDataset(ch chan<- prometheus.Metric, dataset *zfs.Dataset, users, groups map[uint32]string) {
+	name := dataset.Name()
+	pool := dataset.Pool().Name()
+
+	for typ := zfs.UserSpaceType(0); typ < zfs.UserSpaceNumTypes; typ++ {
+		space, err := dataset.UserSpace(typ)
+		if err != nil {
+			log.Printf("error reading %s of dataset '%s': %v", typ, name, err)
+			continue
+		}
+
+		// Projects are only numbered, so have no name label
+		var names map[uint32]string
+		project := false
+		switch typ {
+		case zfs.UserSpaceUserUsed, zfs.UserSpaceUserQuota,
+			zfs.UserSpaceUserObjUsed, zfs.UserSpaceUserObjQuota:
+			names = users
+		case zfs.UserSpaceGroupUsed, zfs.UserSpaceGroupQuota,
+			zfs.UserSpaceGroupObjUsed, zfs.UserSpaceGroupObjQuota:
+			names = groups
+		default:
+			project = true
+		}
+
+		for _, entry := range space {
+			id := strconv.FormatUint(uint64(entry.ID), 10)
+			if entry.Domain != "" {
+				id = entry.Domain + "-" + id
+			}
+
+			labels := []string{name, pool, id}
+			if !project {
+				var owner string
+				if entry.Domain == "" {
+					owner = names[entry.ID]
+				}
+				labels = append(labels, owner)
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				userSpaceDescs[typ],
+				prometheus.GaugeValue,
+				float64(entry.Value),
+				labels...,
+			)
+		}
+	}
+}
+
+// readIDNames reads a passwd(5) or group(5) formatted file, returning the
+// names keyed by ID. Both formats have the name first and the ID third.
+func readIDNames(path string) map[uint32]string {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("error reading names: %v", err)
+		return nil
+	}
+	defer file.Close()
+
+	names := make(map[uint32]string)
+	rd := bufio.NewScanner(file)
+	for rd.Scan() {
+		line := rd.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+	}
+	if err := rd.Err(); err != nil {
+		log.Printf("error reading names from '%s': %v", path, err)
+	}
+
+	return names
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+var (
+	versionInfoDesc = prometheus.NewDesc(
+		"zfs_version_info",
+		"versions of the loaded zfs kernel module and the zfs userland libraries",
+		[]string{"kmod", "userland"}, nil,
+	)
+
+	versionMismatchDesc = prometheus.NewDesc(
+		"zfs_version_mismatch",
+		"whether the zfs kernel module and userland versions differ [0: same, 1: different]",
+		nil, nil,
+	)
+)
+
+type VersionCollector struct {
+	userland string
+}
+
+// Describe implements prometheus.Collector.
+func (collector *VersionCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- versionInfoDesc
+	descs <- versionMismatchDesc
+}
+
+func NewVersionCollector() *VersionCollector {
+	return &VersionCollector{
+		// Linked into the running binary so it can't change
+		userland: zfs.VersionUserland(),
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (collector *VersionCollector) Collect(ch chan<- prometheus.Metric) {
+	// The kernel module can be reloaded (upgraded) underneath us so read it
+	// every time
+	kmod, err := zfs.VersionKernel()
+	if err != nil {
+		log.Printf("error reading zfs kernel module version: %v", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		versionInfoDesc,
+		prometheus.GaugeValue,
+		1, kmod, collector.userland,
+	)
+
+	if kmod == "" {
+		return
+	}
+
+	mismatch := 0.0
+	if kmod != collector.userland {
+		mismatch = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(
+		versionMismatchDesc,
+		prometheus.GaugeValue,
+		mismatch,
+	)
+}
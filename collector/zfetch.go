@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var zfetchMetrics = map[string]kstatMetric{
+	"hits": {
+		prometheus.NewDesc(
+			"zfs_zfetch_hits_total",
+			"number of reads satisfied by an existing prefetch stream",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"misses": {
+		prometheus.NewDesc(
+			"zfs_zfetch_misses_total",
+			"number of reads that didn't match any prefetch stream",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"future": {
+		prometheus.NewDesc(
+			"zfs_zfetch_future_total",
+			"number of reads ahead of a prefetch stream",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"stride": {
+		prometheus.NewDesc(
+			"zfs_zfetch_stride_total",
+			"number of reads within the stride of a prefetch stream",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"past": {
+		prometheus.NewDesc(
+			"zfs_zfetch_past_total",
+			"number of reads behind a prefetch stream",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"max_streams": {
+		prometheus.NewDesc(
+			"zfs_zfetch_max_streams_total",
+			"number of times a new prefetch stream couldn't be created because the stream limit was reached",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"io_issued": {
+		prometheus.NewDesc(
+			"zfs_zfetch_io_issued_total",
+			"number of prefetch I/Os issued",
+			nil, nil,
+		),
+		prometheus.CounterValue,
+	},
+	"io_active": {
+		prometheus.NewDesc(
+			"zfs_zfetch_io_active",
+			"number of prefetch I/Os currently in flight",
+			nil, nil,
+		),
+		prometheus.GaugeValue,
+	},
+}
+
+// NewZfetchCollector exports the prefetcher statistics from zfetchstats.
+func NewZfetchCollector(procfs string) *KstatCollector {
+	return newKstatCollector(procfs, "zfetchstats", zfetchMetrics)
+}
@@ -2,9 +2,14 @@ package collector
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"os"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -123,6 +128,155 @@ var (
 		nil,
 	)
 
+	poolFeatureStateDesc = prometheus.NewDesc(
+		"zfs_pool_feature_state",
+		"pool feature flag state [0: disabled, 1: enabled, 2: active]",
+		[]string{"pool", "feature"},
+		nil,
+	)
+	poolFeatureRefcountDesc = prometheus.NewDesc(
+		"zfs_pool_feature_refcount",
+		"number of on-disk references to an enabled pool feature",
+		[]string{"pool", "feature"},
+		nil,
+	)
+
+	poolDedupEntriesDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_entries",
+		"number of entries in the pool dedup table (DDT)",
+		[]string{"pool"},
+		nil,
+	)
+	poolDedupEntryDiskBytesDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_entry_disk_bytes",
+		"average on-disk size of a dedup table entry in bytes",
+		[]string{"pool"},
+		nil,
+	)
+	poolDedupEntryCoreBytesDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_entry_core_bytes",
+		"average in-core (memory) size of a dedup table entry in bytes",
+		[]string{"pool"},
+		nil,
+	)
+	poolDedupAllocBlocksDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_allocated_blocks",
+		"number of allocated deduplicated blocks by reference count bucket",
+		[]string{"pool", "refcnt"},
+		nil,
+	)
+	poolDedupAllocBytesDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_allocated_bytes",
+		"size of allocated deduplicated blocks by reference count bucket [size: logical, physical, deflated]",
+		[]string{"pool", "refcnt", "size"},
+		nil,
+	)
+	poolDedupRefBlocksDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_referenced_blocks",
+		"number of referenced deduplicated blocks by reference count bucket",
+		[]string{"pool", "refcnt"},
+		nil,
+	)
+	poolDedupRefBytesDesc = prometheus.NewDesc(
+		"zfs_pool_dedup_referenced_bytes",
+		"size of referenced deduplicated blocks by reference count bucket [size: logical, physical, deflated]",
+		[]string{"pool", "refcnt", "size"},
+		nil,
+	)
+
+	poolMultihostDesc = prometheus.NewDesc(
+		"zfs_pool_multihost",
+		"Multihost (MMP) protection of the pool [0: off, 1: on].",
+		[]string{"pool"},
+		nil,
+	)
+	poolMMPSuspendedDesc = prometheus.NewDesc(
+		"zfs_pool_mmp_suspended",
+		"Whether the pool is suspended because multihost writes failed [0: no, 1: yes].",
+		[]string{"pool"},
+		nil,
+	)
+	poolMMPStateDesc = prometheus.NewDesc(
+		"zfs_pool_mmp_state",
+		"Multihost state of the pool, as another host would see it [0: active]. Only pools with multihost=on that this host is writing to are exported.",
+		[]string{"pool", "state"},
+		nil,
+	)
+	poolMMPLastWriteTXGDesc = prometheus.NewDesc(
+		"zfs_pool_mmp_last_write_txg",
+		"TXG of the uberblock in the last multihost write",
+		[]string{"pool"},
+		nil,
+	)
+	poolMMPLastWriteSeqDesc = prometheus.NewDesc(
+		"zfs_pool_mmp_last_write_seq",
+		"Sequence number of the last multihost write, counting writes since its TXG was synced",
+		[]string{"pool"},
+		nil,
+	)
+	poolMMPLastWriteTimeDesc = prometheus.NewDesc(
+		"zfs_pool_mmp_last_write_timestamp_seconds",
+		"Unix timestamp of the last multihost write",
+		[]string{"pool"},
+		nil,
+	)
+	poolSuspendedDesc = prometheus.NewDesc(
+		"zfs_pool_suspended",
+		"Whether I/O to the pool is suspended [0: no, 1: yes]. reason is one of none, ioerr or mmp.",
+		[]string{"pool", "reason"},
+		nil,
+	)
+	poolFailmodeDesc = prometheus.NewDesc(
+		"zfs_pool_failmode",
+		"Behaviour of the pool on catastrophic failure [0: wait, 1: continue, 2: panic].",
+		[]string{"pool", "mode"},
+		nil,
+	)
+	poolCollectTimeouts = prometheus.NewDesc(
+		"zfs_pool_collect_timeouts_total",
+		"number of times collecting ZFS metrics timed out while collecting the pool",
+		[]string{"pool"},
+		nil,
+	)
+
+	poolInfoDesc = prometheus.NewDesc(
+		"zfs_pool_info",
+		"Identity and host metadata of the pool.",
+		[]string{"pool", "guid", "version", "hostname", "hostid", "altroot", "cachefile", "comment", "bootfs"},
+		nil,
+	)
+	poolHostidMismatchDesc = prometheus.NewDesc(
+		"zfs_pool_hostid_mismatch",
+		"Whether the hostid the pool was imported with differs from the current hostid of this system, such as after /etc/hostid changed [0: same, 1: different].",
+		[]string{"pool"},
+		nil,
+	)
+
+	poolImportTimeDesc = prometheus.NewDesc(
+		"zfs_pool_import_timestamp_seconds",
+		"Unix timestamp of when the pool was imported (loaded).",
+		[]string{"pool"},
+		nil,
+	)
+	poolSyncedTXGDesc = prometheus.NewDesc(
+		"zfs_pool_last_synced_txg",
+		"Last TXG synced to disk. Requires zfs_txg_history > 0",
+		[]string{"pool"},
+		nil,
+	)
+	poolConfigTXGDesc = prometheus.NewDesc(
+		"zfs_pool_config_txg",
+		"TXG in which the pool configuration was last changed.",
+		[]string{"pool"},
+		nil,
+	)
+
+	poolCollectRunning = prometheus.NewDesc(
+		"zfs_pool_collect_running",
+		"Whether a collection that timed out is still running, so pools aren't being collected [0: no, 1: yes].",
+		nil,
+		nil,
+	)
 	poolCollectErrors = prometheus.NewDesc(
 		"zfs_pool_collect_errors_total",
 		"errors collecting ZFS metrics",
@@ -132,9 +286,22 @@ var (
 )
 
 type ZpoolCollector struct {
-	libzfs *zfs.LibZFS
+	libzfs  *zfs.LibZFS
+	procfs  string
+	timeout time.Duration
 
-	poolErrors map[string]int
+	// Collections that time out are left running in the background and can
+	// still count errors when they finish, so these must be locked.
+	lock         sync.Mutex
+	poolErrors   map[string]int
+	poolTimeouts map[string]int
+	// pools are the names of the pools last opened, and current the pool
+	// being collected, if any
+	pools   []string
+	current string
+	// running is set while a timed out collection hasn't returned. Later
+	// collections are skipped until it does, as it's still using libzfs.
+	running bool
 }
 
 // Describe implements prometheus.Collector.
@@ -153,18 +320,118 @@ func (collector *ZpoolCollector) Describe(descs chan<- *prometheus.Desc) {
 	descs <- poolScrubStatus
 	descs <- poolScrubStartTimeDesc
 	descs <- poolScrubEndTimeDesc
+	descs <- poolFeatureStateDesc
+	descs <- poolFeatureRefcountDesc
+	descs <- poolDedupEntriesDesc
+	descs <- poolDedupEntryDiskBytesDesc
+	descs <- poolDedupEntryCoreBytesDesc
+	descs <- poolDedupAllocBlocksDesc
+	descs <- poolDedupAllocBytesDesc
+	descs <- poolDedupRefBlocksDesc
+	descs <- poolDedupRefBytesDesc
+	descs <- poolMultihostDesc
+	descs <- poolMMPSuspendedDesc
+	descs <- poolMMPStateDesc
+	descs <- poolMMPLastWriteTXGDesc
+	descs <- poolMMPLastWriteSeqDesc
+	descs <- poolMMPLastWriteTimeDesc
+	descs <- poolSuspendedDesc
+	descs <- poolFailmodeDesc
+	descs <- poolCollectTimeouts
+	descs <- poolCollectRunning
+	descs <- poolInfoDesc
+	descs <- poolHostidMismatchDesc
+	descs <- poolImportTimeDesc
+	descs <- poolSyncedTXGDesc
+	descs <- poolConfigTXGDesc
 	descs <- poolCollectErrors
 }
 
-func NewZpoolCollector(libzfs *zfs.LibZFS) *ZpoolCollector {
+// NewZpoolCollector creates a pool collector. Collecting all pools is given
+// timeout before it's abandoned, so that a hung pool doesn't block the scrape.
+// A zero timeout waits indefinitely.
+func NewZpoolCollector(libzfs *zfs.LibZFS, procfs string, timeout time.Duration) *ZpoolCollector {
 	return &ZpoolCollector{
-		libzfs:     libzfs,
-		poolErrors: make(map[string]int),
+		libzfs:       libzfs,
+		procfs:       procfs,
+		timeout:      timeout,
+		poolErrors:   make(map[string]int),
+		poolTimeouts: make(map[string]int),
 	}
 }
 
-// Collect implements prometheus.Collector.
+// Collect implements prometheus.Collector. Metrics are forwarded until the
+// collector timeout, and collected in order so the most important, such as the
+// pool state, are emitted even if a later libzfs call hangs.
 func (collector *ZpoolCollector) Collect(ch chan<- prometheus.Metric) {
+	if collector.timeout <= 0 {
+		collector.collect(ch)
+		collector.collectCounters(ch)
+		return
+	}
+
+	collector.lock.Lock()
+	busy := collector.running
+	collector.running = true
+	collector.lock.Unlock()
+	if busy {
+		// libzfs handles aren't thread-safe, and another call on a hung pool
+		// would only hang too
+		log.Printf("skipping pools as a previous collection is still running")
+		ch <- prometheus.MustNewConstMetric(poolCollectRunning, prometheus.GaugeValue, 1)
+		collector.collectCounters(ch)
+		return
+	}
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		collector.collect(metrics)
+		collector.lock.Lock()
+		collector.running = false
+		collector.lock.Unlock()
+		close(metrics)
+	}()
+
+	timer := time.NewTimer(collector.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case metric, ok := <-metrics:
+			if !ok {
+				ch <- prometheus.MustNewConstMetric(poolCollectRunning, prometheus.GaugeValue, 0)
+				collector.collectCounters(ch)
+				return
+			}
+			ch <- metric
+
+		case <-timer.C:
+			collector.lock.Lock()
+			name := collector.current
+			if name != "" {
+				collector.poolTimeouts[name]++
+			}
+			collector.lock.Unlock()
+			if name != "" {
+				log.Printf("timed out collecting pool '%s' after %s", name, collector.timeout)
+			} else {
+				log.Printf("timed out opening pools after %s", collector.timeout)
+			}
+
+			// Let the collection finish in its own time, if it ever does
+			go func() {
+				for range metrics {
+				}
+			}()
+
+			ch <- prometheus.MustNewConstMetric(poolCollectRunning, prometheus.GaugeValue, 1)
+			collector.collectCounters(ch)
+			return
+		}
+	}
+}
+
+// collect collects and closes every pool in turn.
+func (collector *ZpoolCollector) collect(ch chan<- prometheus.Metric) {
 	pools, err := collector.libzfs.PoolOpenAll()
 	if err != nil {
 		log.Printf("error opening pools: %v", err)
@@ -172,19 +439,60 @@ func (collector *ZpoolCollector) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 
+	names := make([]string, len(pools))
+	for i, pool := range pools {
+		names[i] = pool.Name()
+	}
+	collector.lock.Lock()
+	collector.pools = names
+	collector.lock.Unlock()
+
 	for _, pool := range pools {
+		collector.lock.Lock()
+		collector.current = pool.Name()
+		collector.lock.Unlock()
+
 		collector.collectPool(ch, pool)
 		pool.Close()
 	}
 
+	collector.lock.Lock()
+	collector.current = ""
+	collector.lock.Unlock()
+
 	runtime.GC()
 }
 
+func (collector *ZpoolCollector) poolError(name string) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	collector.poolErrors[name]++
+}
+
+// collectCounters exports the collection timeouts and errors of the pools last
+// opened.
+func (collector *ZpoolCollector) collectCounters(ch chan<- prometheus.Metric) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	for _, name := range collector.pools {
+		ch <- prometheus.MustNewConstMetric(
+			poolCollectTimeouts,
+			prometheus.CounterValue,
+			float64(collector.poolTimeouts[name]),
+			name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			poolCollectErrors,
+			prometheus.CounterValue,
+			float64(collector.poolErrors[name]),
+			name,
+		)
+	}
+}
+
 func (collector *ZpoolCollector) collectPool(metrics chan<- prometheus.Metric, pool *zfs.Pool) {
 	name := pool.Name()
-	if _, ok := collector.poolErrors[name]; !ok {
-		collector.poolErrors[name] = 0
-	}
 
 	state := pool.State()
 	metrics <- prometheus.MustNewConstMetric(
@@ -202,19 +510,45 @@ func (collector *ZpoolCollector) collectPool(metrics chan<- prometheus.Metric, p
 		name, strings.ToLower(status.String()),
 	)
 
+	// Collect suspension state early, before any calls that could hang on a
+	// suspended pool
+	err := collector.collectSuspended(metrics, pool)
+	if err != nil {
+		log.Printf("unable to read suspended state for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+
+	err = collector.collectFailmode(metrics, pool)
+	if err != nil {
+		log.Printf("unable to read failmode for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+
+	err = collector.collectInfo(metrics, pool)
+	if err != nil {
+		log.Printf("unable to read info for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+
+	err = collector.collectTXG(metrics, pool)
+	if err != nil {
+		log.Printf("unable to read txg for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+
 	roProp, err := pool.Get(zfs.PoolPropReadonly)
 	if err != nil {
 		log.Printf("error getting property '%s' of pool '%s': %v",
 			zfs.PoolPropReadonly, name, err,
 		)
-		collector.poolErrors[name]++
+		collector.poolError(name)
 
 	} else {
 		readonly := 0.0
 
 		if roProp.Value != "on" && roProp.Value != "off" {
 			log.Printf("readonly value is unexpected: %s", roProp.Value)
-			collector.poolErrors[name]++
+			collector.poolError(name)
 
 		} else {
 			if roProp.Value == "on" {
@@ -234,14 +568,14 @@ func (collector *ZpoolCollector) collectPool(metrics chan<- prometheus.Metric, p
 	vdt, err = pool.VDevTree()
 	if err != nil {
 		log.Printf("unable to read vdevtree for pool '%s': %v", name, err)
-		collector.poolErrors[name]++
+		collector.poolError(name)
 	} else {
 		// Pass empty "parent" because pools are top-level. Label will be empty
 		// and appear absent in Prometheus.
 		err = collector.collectVdev(metrics, vdt, name, "")
 		if err != nil {
 			log.Printf("unable to read vdevtree stats for pool '%s': %v", name, err)
-			collector.poolErrors[name]++
+			collector.poolError(name)
 		}
 	}
 
@@ -249,7 +583,7 @@ func (collector *ZpoolCollector) collectPool(metrics chan<- prometheus.Metric, p
 	if err != nil {
 		if !errors.Is(err, zfs.ErrNotFound) {
 			log.Printf("unable to read scan statistics for pool '%s': %v", name, err)
-			collector.poolErrors[name]++
+			collector.poolError(name)
 		}
 	} else {
 		if scan.Func == zfs.ScanScrub {
@@ -280,12 +614,383 @@ func (collector *ZpoolCollector) collectPool(metrics chan<- prometheus.Metric, p
 		}
 	}
 
-	metrics <- prometheus.MustNewConstMetric(
-		poolCollectErrors,
-		prometheus.CounterValue,
-		float64(collector.poolErrors[name]),
+	err = collector.collectFeatures(metrics, pool)
+	if err != nil {
+		log.Printf("unable to read features for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+
+	err = collector.collectDedup(metrics, pool)
+	if err != nil && !errors.Is(err, zfs.ErrNotFound) {
+		log.Printf("unable to read dedup statistics for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+
+	err = collector.collectMMP(metrics, pool, status)
+	if err != nil {
+		log.Printf("unable to read multihost state for pool '%s': %v", name, err)
+		collector.poolError(name)
+	}
+}
+
+func (collector *ZpoolCollector) collectSuspended(ch chan<- prometheus.Metric, pool *zfs.Pool) error {
+	name := pool.Name()
+
+	reason, err := pool.Suspended()
+	if err != nil {
+		return err
+	}
+	suspended := 0.0
+	if reason != zfs.SuspendNone {
+		suspended = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolSuspendedDesc,
+		prometheus.GaugeValue,
+		suspended,
+		name, reason.String(),
+	)
+	return nil
+}
+
+func (collector *ZpoolCollector) collectFailmode(ch chan<- prometheus.Metric, pool *zfs.Pool) error {
+	name := pool.Name()
+
+	prop, err := pool.Get(zfs.PoolPropFailuremode)
+	if err != nil {
+		return fmt.Errorf("error getting property '%s': %w", zfs.PoolPropFailuremode, err)
+	}
+	var failmode zfs.FailureMode
+	switch prop.Value {
+	case "wait":
+		failmode = zfs.FailureModeWait
+	case "continue":
+		failmode = zfs.FailureModeContinue
+	case "panic":
+		failmode = zfs.FailureModePanic
+	default:
+		return fmt.Errorf("failmode value is unexpected: %s", prop.Value)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolFailmodeDesc,
+		prometheus.GaugeValue,
+		float64(failmode),
+		name, failmode.String(),
+	)
+
+	return nil
+}
+
+func (collector *ZpoolCollector) collectInfo(ch chan<- prometheus.Metric, pool *zfs.Pool) error {
+	name := pool.Name()
+
+	config, err := pool.Config()
+	if err != nil {
+		return err
+	}
+
+	guid, err := config.LookupUint64(zfs.PoolConfigPoolGUID)
+	if err != nil {
+		return fmt.Errorf("error reading '%s': %w", zfs.PoolConfigPoolGUID, err)
+	}
+	version, err := config.LookupUint64(zfs.PoolConfigVersion)
+	if err != nil {
+		return fmt.Errorf("error reading '%s': %w", zfs.PoolConfigVersion, err)
+	}
+
+	// The remainder are all optional
+	hostname, err := config.LookupString(zfs.PoolConfigHostname)
+	if err != nil && !errors.Is(err, zfs.ErrNotFound) {
+		return fmt.Errorf("error reading '%s': %w", zfs.PoolConfigHostname, err)
+	}
+	comment, err := config.LookupString(zfs.PoolConfigComment)
+	if err != nil && !errors.Is(err, zfs.ErrNotFound) {
+		return fmt.Errorf("error reading '%s': %w", zfs.PoolConfigComment, err)
+	}
+	hostid, err := config.LookupUint64(zfs.PoolConfigHostid)
+	hasHostid := err == nil
+	if err != nil && !errors.Is(err, zfs.ErrNotFound) {
+		return fmt.Errorf("error reading '%s': %w", zfs.PoolConfigHostid, err)
+	}
+
+	props := make(map[zfs.PoolProperty]string)
+	for _, prop := range []zfs.PoolProperty{zfs.PoolPropAltroot, zfs.PoolPropCachefile, zfs.PoolPropBootfs} {
+		val, err := pool.Get(prop)
+		if err != nil {
+			return fmt.Errorf("error getting property '%s': %w", prop, err)
+		}
+		// Unset properties read as "-"
+		if val.Value != "-" {
+			props[prop] = val.Value
+		}
+	}
+
+	hostidStr := ""
+	if hasHostid {
+		hostidStr = fmt.Sprintf("%08x", hostid)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		poolInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		name,
+		strconv.FormatUint(guid, 10),
+		strconv.FormatUint(version, 10),
+		hostname,
+		hostidStr,
+		props[zfs.PoolPropAltroot],
+		props[zfs.PoolPropCachefile],
+		comment,
+		props[zfs.PoolPropBootfs],
+	)
+
+	if hasHostid {
+		mismatch := 0.0
+		if hostid != zfs.SystemHostID() {
+			mismatch = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			poolHostidMismatchDesc,
+			prometheus.GaugeValue,
+			mismatch,
+			name,
+		)
+	}
+
+	return nil
+}
+
+func (collector *ZpoolCollector) collectTXG(ch chan<- prometheus.Metric, pool *zfs.Pool) error {
+	name := pool.Name()
+
+	loaded, err := pool.LoadedTime()
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolImportTimeDesc,
+		prometheus.GaugeValue,
+		float64(loaded.UnixNano())/1e9,
+		name,
+	)
+
+	config, err := pool.Config()
+	if err != nil {
+		return err
+	}
+	configTXG, err := config.LookupUint64(zfs.PoolConfigPoolTXG)
+	if err != nil {
+		return fmt.Errorf("error reading '%s': %w", zfs.PoolConfigPoolTXG, err)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolConfigTXGDesc,
+		prometheus.GaugeValue,
+		float64(configTXG),
+		name,
+	)
+
+	txg, err := lastSyncedTXG(collector.procfs, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolSyncedTXGDesc,
+		prometheus.GaugeValue,
+		float64(txg),
 		name,
 	)
+
+	return nil
+}
+
+func (collector *ZpoolCollector) collectFeatures(ch chan<- prometheus.Metric, pool *zfs.Pool) error {
+	features, err := pool.Features()
+	if err != nil {
+		return err
+	}
+
+	name := pool.Name()
+	for _, feature := range features {
+		var state float64
+		switch feature.State {
+		case zfs.FDISABLED:
+			state = 0
+		case zfs.FENABLED:
+			state = 1
+		case zfs.FACTIVE:
+			state = 2
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			poolFeatureStateDesc,
+			prometheus.GaugeValue,
+			state,
+			name, feature.Name,
+		)
+
+		if feature.State != zfs.FDISABLED {
+			ch <- prometheus.MustNewConstMetric(
+				poolFeatureRefcountDesc,
+				prometheus.GaugeValue,
+				float64(feature.Refcount),
+				name, feature.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (collector *ZpoolCollector) collectDedup(ch chan<- prometheus.Metric, pool *zfs.Pool) error {
+	ddt, err := pool.DDTStats()
+	if err != nil {
+		return err
+	}
+
+	name := pool.Name()
+	ch <- prometheus.MustNewConstMetric(
+		poolDedupEntriesDesc,
+		prometheus.GaugeValue,
+		float64(ddt.Object.Count),
+		name,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		poolDedupEntryDiskBytesDesc,
+		prometheus.GaugeValue,
+		float64(ddt.Object.DSpace),
+		name,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		poolDedupEntryCoreBytesDesc,
+		prometheus.GaugeValue,
+		float64(ddt.Object.MSpace),
+		name,
+	)
+
+	for bucket, stat := range ddt.Histogram {
+		// Like `zpool status -D`, skip empty buckets to keep cardinality sane
+		if stat.Blocks == 0 {
+			continue
+		}
+
+		refcnt := strconv.FormatUint(1<<bucket, 10)
+		ch <- prometheus.MustNewConstMetric(
+			poolDedupAllocBlocksDesc,
+			prometheus.GaugeValue,
+			float64(stat.Blocks),
+			name, refcnt,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			poolDedupRefBlocksDesc,
+			prometheus.GaugeValue,
+			float64(stat.RefBlocks),
+			name, refcnt,
+		)
+
+		sizes := []struct {
+			size       string
+			alloc, ref uint64
+		}{
+			{"logical", stat.LSize, stat.RefLSize},
+			{"physical", stat.PSize, stat.RefPSize},
+			{"deflated", stat.DSize, stat.RefDSize},
+		}
+		for _, size := range sizes {
+			ch <- prometheus.MustNewConstMetric(
+				poolDedupAllocBytesDesc,
+				prometheus.GaugeValue,
+				float64(size.alloc),
+				name, refcnt, size.size,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				poolDedupRefBytesDesc,
+				prometheus.GaugeValue,
+				float64(size.ref),
+				name, refcnt, size.size,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (collector *ZpoolCollector) collectMMP(ch chan<- prometheus.Metric, pool *zfs.Pool, status zfs.PoolStatus) error {
+	name := pool.Name()
+
+	prop, err := pool.Get(zfs.PoolPropMultiHost)
+	if err != nil {
+		return fmt.Errorf("error getting property '%s': %w", zfs.PoolPropMultiHost, err)
+	}
+	multihost := 0.0
+	if prop.Value == "on" {
+		multihost = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolMultihostDesc,
+		prometheus.GaugeValue,
+		multihost,
+		name,
+	)
+
+	suspended := 0.0
+	if status == zfs.PoolStatusIOFailureMMP {
+		suspended = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(
+		poolMMPSuspendedDesc,
+		prometheus.GaugeValue,
+		suspended,
+		name,
+	)
+
+	if multihost == 0 {
+		return nil
+	}
+
+	// The uberblocks of multihost writes are read from the devices, which
+	// may not be accessible, such as in a container. The history kstat has
+	// the same but is usually disabled.
+	write, err := pool.LastMMPWrite()
+	if errors.Is(err, zfs.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		history, herr := lastMMPWrite(collector.procfs, name)
+		if herr != nil {
+			return err
+		}
+		write = zfs.MMPWrite{TXG: history.txg, Timestamp: time.Unix(int64(history.timestamp), 0)}
+	} else {
+		ch <- prometheus.MustNewConstMetric(
+			poolMMPLastWriteSeqDesc,
+			prometheus.GaugeValue,
+			float64(write.Seq),
+			name,
+		)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		poolMMPStateDesc,
+		prometheus.GaugeValue,
+		float64(zfs.MMPStateActive),
+		name, zfs.MMPStateActive.String(),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		poolMMPLastWriteTXGDesc,
+		prometheus.GaugeValue,
+		float64(write.TXG),
+		name,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		poolMMPLastWriteTimeDesc,
+		prometheus.GaugeValue,
+		float64(write.Timestamp.Unix()),
+		name,
+	)
+
+	return nil
 }
 
 func (collector *ZpoolCollector) collectVdev(ch chan<- prometheus.Metric, vdt zfs.VDevTree, pool, parent string) error {
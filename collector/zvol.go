@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/frebib/zfs-exporter/zfs"
+)
+
+func newVolumeInfoDesc(b *descBuilder) *prometheus.Desc {
+	return b.desc(
+		"zfs_volume_info",
+		"block device of a zfs volume, empty if there isn't one, and how it is exposed (volmode)",
+		"volmode", "device",
+	)
+}
+
+// zvolDevice resolves the /dev/zvol/<name> symlink of a volume to the name of
+// its block device, such as "zd0".
+func zvolDevice(devfs, name string) (string, error) {
+	dev, err := filepath.EvalSymlinks(filepath.Join(devfs, "zvol", name))
+	if err != nil {
+		return "", err
+	}
+	dev = filepath.Base(dev)
+	if !strings.HasPrefix(dev, "zd") {
+		return "", fmt.Errorf("unexpected device '%s' for volume '%s'", dev, name)
+	}
+	return dev, nil
+}
+
+func (collector *DatasetCollector) collectVolume(ch chan<- prometheus.Metric, volume *zfs.Dataset, labels []string) {
+	name := volume.Name()
+
+	var volmode string
+	val, err := volume.Get(zfs.DatasetPropVolMode)
+	if err != nil {
+		collector.datasetErrors[name]++
+		log.Printf("error reading volmode of '%s': %v", name, err)
+	} else {
+		volmode = datasetPropertyValue(val)
+	}
+
+	// Volumes with volmode=none, or while the pool is importing, have no
+	// device at all
+	device, _ := zvolDevice(collector.opts.DevPath, name)
+
+	ch <- prometheus.MustNewConstMetric(
+		collector.volume, prometheus.GaugeValue, 1,
+		append(labels[:len(labels):len(labels)], volmode, device)...,
+	)
+}
@@ -0,0 +1,189 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// diskstatsSectorSize is the size of the sectors counted in /proc/diskstats,
+// regardless of the block size of the device
+const diskstatsSectorSize = 512
+
+type diskstatsMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	scale     float64
+}
+
+var (
+	zvolIOLabels = []string{"name", "pool", "device"}
+
+	// zvolIOMetrics are indexed by the field in /proc/diskstats after the
+	// device name, see Documentation/admin-guide/iostats.rst in Linux. The
+	// discard and flush fields are missing in older kernels.
+	zvolIOMetrics = []diskstatsMetric{
+		{zvolIODesc("reads_completed_total", "number of reads completed"), prometheus.CounterValue, 1},
+		{zvolIODesc("reads_merged_total", "number of adjacent reads merged"), prometheus.CounterValue, 1},
+		{zvolIODesc("read_bytes_total", "number of bytes read"), prometheus.CounterValue, diskstatsSectorSize},
+		{zvolIODesc("read_time_seconds_total", "time spent reading in seconds"), prometheus.CounterValue, 0.001},
+		{zvolIODesc("writes_completed_total", "number of writes completed"), prometheus.CounterValue, 1},
+		{zvolIODesc("writes_merged_total", "number of adjacent writes merged"), prometheus.CounterValue, 1},
+		{zvolIODesc("written_bytes_total", "number of bytes written"), prometheus.CounterValue, diskstatsSectorSize},
+		{zvolIODesc("write_time_seconds_total", "time spent writing in seconds"), prometheus.CounterValue, 0.001},
+		{zvolIODesc("io_now", "number of I/Os currently in flight"), prometheus.GaugeValue, 1},
+		{zvolIODesc("io_time_seconds_total", "time spent with I/Os in flight in seconds"), prometheus.CounterValue, 0.001},
+		{zvolIODesc("io_time_weighted_seconds_total", "time spent waiting for I/Os, multiplied by the number in flight, in seconds"), prometheus.CounterValue, 0.001},
+		{zvolIODesc("discards_completed_total", "number of discards completed"), prometheus.CounterValue, 1},
+		{zvolIODesc("discards_merged_total", "number of adjacent discards merged"), prometheus.CounterValue, 1},
+		{zvolIODesc("discarded_bytes_total", "number of bytes discarded"), prometheus.CounterValue, diskstatsSectorSize},
+		{zvolIODesc("discard_time_seconds_total", "time spent discarding in seconds"), prometheus.CounterValue, 0.001},
+		{zvolIODesc("flush_requests_total", "number of flushes completed"), prometheus.CounterValue, 1},
+		{zvolIODesc("flush_time_seconds_total", "time spent flushing in seconds"), prometheus.CounterValue, 0.001},
+	}
+)
+
+func zvolIODesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc("zfs_volume_"+name, help+" by the zfs volume", zvolIOLabels, nil)
+}
+
+// ZvolIOCollector exports the block I/O statistics of each volume from
+// /proc/diskstats, labelled by the name of the volume rather than its zd
+// device.
+type ZvolIOCollector struct {
+	devfs     string
+	diskstats string
+}
+
+// Describe implements prometheus.Collector.
+func (collector *ZvolIOCollector) Describe(descs chan<- *prometheus.Desc) {
+	for _, metric := range zvolIOMetrics {
+		descs <- metric.desc
+	}
+}
+
+func NewZvolIOCollector(procfs, devfs string) *ZvolIOCollector {
+	return &ZvolIOCollector{
+		devfs:     devfs,
+		diskstats: filepath.Join(procfs, "diskstats"),
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (collector *ZvolIOCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := readDiskstats(collector.diskstats, "zd")
+	if err != nil {
+		log.Printf("error reading diskstats: %v", err)
+		return
+	}
+
+	volumes, err := readZvolLinks(filepath.Join(collector.devfs, "zvol"))
+	if err != nil {
+		log.Printf("error reading volume devices: %v", err)
+		return
+	}
+
+	for device, fields := range stats {
+		name, ok := volumes[device]
+		if !ok {
+			// Partitions, or volumes whose links are yet to be made
+			continue
+		}
+
+		pool, _, _ := strings.Cut(name, "/")
+		for i, value := range fields {
+			if i >= len(zvolIOMetrics) {
+				break
+			}
+			metric := zvolIOMetrics[i]
+			ch <- prometheus.MustNewConstMetric(
+				metric.desc, metric.valueType,
+				float64(value)*metric.scale,
+				name, pool, device,
+			)
+		}
+	}
+}
+
+var zvolDeviceName = regexp.MustCompile("^zd[0-9]+$")
+
+// readZvolLinks walks the /dev/zvol symlinks made by udev, returning the name
+// of the volume of each zd device. Links to partitions are left out.
+func readZvolLinks(dir string) (map[string]string, error) {
+	volumes := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				// No volumes at all
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		dev, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			// Removed while walking
+			return nil
+		}
+		dev = filepath.Base(dev)
+		if !zvolDeviceName.MatchString(dev) {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		volumes[dev] = filepath.ToSlash(name)
+		return nil
+	})
+	return volumes, err
+}
+
+// readDiskstats parses /proc/diskstats, returning the statistics of each
+// device with a name starting with prefix.
+func readDiskstats(path, prefix string) (map[string][]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	/*
+		Each line is the major and minor numbers and name of a device, followed
+		by 11, 15 or 17 counters depending on the kernel version:
+
+		 230       0 zd0 1234 0 56789 100 4321 0 98765 200 0 300 300 0 0 0 0 0 0
+	*/
+	stats := make(map[string][]uint64)
+	rd := bufio.NewScanner(file)
+	for rd.Scan() {
+		fields := strings.Fields(rd.Text())
+		if len(fields) < 3 || !strings.HasPrefix(fields[2], prefix) {
+			continue
+		}
+
+		values := make([]uint64, 0, len(fields)-3)
+		for _, field := range fields[3:] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed diskstats value for '%s': %w", fields[2], err)
+			}
+			values = append(values, value)
+		}
+		stats[fields[2]] = values
+	}
+
+	return stats, rd.Err()
+}
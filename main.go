@@ -8,6 +8,10 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -24,8 +28,62 @@ var (
 	listenAddress = flag.String("web.listen-address", ":9254", "Address on which to expose metrics and web interface.")
 	metricsPath   = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
 	webConfigFile = flag.String("web.config.file", "", "Path to web-config file")
+	procfsPath    = flag.String("path.procfs", "/proc", "procfs mountpoint.")
+	sysfsPath     = flag.String("path.sysfs", "/sys", "sysfs mountpoint.")
+	devfsPath     = flag.String("path.devfs", "/dev", "devfs mountpoint.")
+
+	collectPool    = flag.Bool("collector.pool", true, "Enable the pool collector.")
+	collectVersion = flag.Bool("collector.version", true, "Enable the zfs version collector.")
+	collectDataset = flag.Bool("collector.dataset", true, "Enable the dataset collector.")
+	collectZfetch  = flag.Bool("collector.zfetch", true, "Enable the prefetch (zfetchstats) collector.")
+	collectDbuf    = flag.Bool("collector.dbuf", true, "Enable the dbuf cache (dbufstats) collector.")
+	collectABD     = flag.Bool("collector.abd", true, "Enable the ABD (abdstats) collector.")
+	collectParams  = flag.Bool("collector.module-params", true, "Enable the kernel module parameter collector.")
+	collectImport  = flag.Bool("collector.import", false, "Enable the importable pool collector. Scans the labels of all devices on every scrape.")
+	collectZvolIO  = flag.Bool("collector.zvol-io", true, "Enable the volume block I/O (diskstats) collector.")
+	collectUser    = flag.Bool("collector.userspace", false, "Enable the per-user, per-group and per-project space accounting collector.")
+
+	poolTimeout      = flag.Duration("collector.pool.timeout", 10*time.Second, "Maximum time to spend collecting all pools. Zero waits indefinitely.")
+	datasetBookmarks = flag.Bool("collector.dataset.bookmarks", false, "Collect bookmarks and summarise them per dataset.")
+	datasetMounts    = flag.String("collector.dataset.mountinfo", "", "Mount table to check filesystems are mounted against. Defaults to 1/mountinfo in -path.procfs, the mount table of init, so that the host's mounts are seen from a container sharing its PID namespace.")
+	datasetProps     = flag.String("collector.dataset.properties", strings.Join(collector.DefaultDatasetProperties, ","), "Comma-separated dataset properties to collect, or \"all\" for every numeric property, other than GUIDs, that this version knows of.")
+	datasetInfo      = flag.String("collector.dataset.info-properties", "", "Comma-separated dataset properties to export as labels on zfs_dataset_properties_info of each filesystem and volume.")
+	datasetSnaps     = flag.String("collector.dataset.snapshots", ".*", "Regexp of snapshot names to export the properties of individually. Snapshots are only summarised per dataset if empty.")
+	datasetSources   = flag.String("collector.dataset.property-sources", "", "Comma-separated dataset properties to report the value and source (default, local, inherited or received) of.")
+	datasetUser      = flag.String("collector.dataset.user-properties", "", "Comma-separated user properties to add as labels to dataset metrics, each as \"property\" or \"property=label\".")
+	importDirs       = flag.String("collector.import.dirs", "", "Comma-separated directories to search for importable pools. Searches the default device paths if empty.")
+	userPasswd       = flag.String("collector.userspace.passwd", "", "passwd file used to name user IDs, such as /etc/passwd. Users are unnamed if empty.")
+	userGroup        = flag.String("collector.userspace.group", "", "group file used to name group IDs, such as /etc/group. Groups are unnamed if empty.")
+	paramsInclude    = flag.String("collector.module-params.include", "", "Regexp of module parameters to collect. Collects all parameters if empty.")
+	paramsExclude    = flag.String("collector.module-params.exclude", "", "Regexp of module parameters to skip.")
 )
 
+// snapshotPolicies collects each -collector.dataset.snapshot-policy flag.
+var snapshotPolicies snapshotPolicyFlag
+
+type snapshotPolicyFlag []collector.SnapshotPolicy
+
+func (policies *snapshotPolicyFlag) String() string {
+	strs := make([]string, len(*policies))
+	for i, policy := range *policies {
+		strs[i] = policy.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+func (policies *snapshotPolicyFlag) Set(str string) error {
+	policy, err := collector.ParseSnapshotPolicy(str)
+	if err != nil {
+		return err
+	}
+	*policies = append(*policies, policy)
+	return nil
+}
+
+func init() {
+	flag.Var(&snapshotPolicies, "collector.dataset.snapshot-policy", "Snapshot retention policy as name:datasets:pattern:max-age:min-count, such as \"hourly:^tank/home:_hourly$:2h:24\", checking the snapshots of datasets matching the datasets regexp. Can be repeated.")
+}
+
 func main() {
 	flag.Parse()
 
@@ -45,8 +103,72 @@ func main() {
 	registry.MustRegister(collectors.NewGoCollector())
 	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{ReportErrors: true}))
 	registry.MustRegister(version.NewCollector("zfs"))
-	registry.MustRegister(collector.NewZpoolCollector(libzfs))
-	registry.MustRegister(collector.NewDatasetCollector(libzfs))
+	if *collectPool {
+		registry.MustRegister(collector.NewZpoolCollector(libzfs, *procfsPath, *poolTimeout))
+	}
+	if *collectVersion {
+		registry.MustRegister(collector.NewVersionCollector())
+	}
+	if *collectDataset {
+		var opts collector.DatasetCollectorOpts
+		opts.Properties, err = collector.ParseDatasetProperties(strings.Split(*datasetProps, ","))
+		if err != nil {
+			log.Fatalf("invalid -collector.dataset.properties: %v", err)
+		}
+		opts.InfoProperties, err = collector.ParseDatasetInfoProperties(strings.Split(*datasetInfo, ","))
+		if err != nil {
+			log.Fatalf("invalid -collector.dataset.info-properties: %v", err)
+		}
+		opts.SourceProperties, err = collector.LookupDatasetProperties(strings.Split(*datasetSources, ","))
+		if err != nil {
+			log.Fatalf("invalid -collector.dataset.property-sources: %v", err)
+		}
+		opts.DevPath = *devfsPath
+		opts.MountinfoPath = *datasetMounts
+		if opts.MountinfoPath == "" {
+			opts.MountinfoPath = filepath.Join(*procfsPath, "1/mountinfo")
+		}
+		opts.Bookmarks = *datasetBookmarks
+		opts.SnapshotPolicies = snapshotPolicies
+		opts.SnapshotFilter = compileFlagRegexp("collector.dataset.snapshots", *datasetSnaps)
+		opts.UserProperties, err = collector.ParseUserPropertyLabels(strings.Split(*datasetUser, ","))
+		if err != nil {
+			log.Fatalf("invalid -collector.dataset.user-properties: %v", err)
+		}
+		datasets, err := collector.NewDatasetCollector(libzfs, opts)
+		if err != nil {
+			log.Fatalf("invalid -collector.dataset.user-properties: %v", err)
+		}
+		registry.MustRegister(datasets)
+	}
+	if *collectZvolIO {
+		registry.MustRegister(collector.NewZvolIOCollector(*procfsPath, *devfsPath))
+	}
+	if *collectZfetch {
+		registry.MustRegister(collector.NewZfetchCollector(*procfsPath))
+	}
+	if *collectDbuf {
+		registry.MustRegister(collector.NewDbufCollector(*procfsPath))
+	}
+	if *collectABD {
+		registry.MustRegister(collector.NewABDCollector(*procfsPath))
+	}
+	if *collectImport {
+		var dirs []string
+		if *importDirs != "" {
+			dirs = strings.Split(*importDirs, ",")
+		}
+		registry.MustRegister(collector.NewImportCollector(libzfs, dirs))
+	}
+	if *collectUser {
+		registry.MustRegister(collector.NewUserSpaceCollector(libzfs, *userPasswd, *userGroup))
+	}
+	if *collectParams {
+		registry.MustRegister(collector.NewModuleParameterCollector(*sysfsPath,
+			compileFlagRegexp("collector.module-params.include", *paramsInclude),
+			compileFlagRegexp("collector.module-params.exclude", *paramsExclude),
+		))
+	}
 
 	args := flag.Args()
 	if len(args) == 1 && args[0] == "once" {
@@ -85,3 +207,16 @@ func main() {
 		log.Fatalf("%s", err)
 	}
 }
+
+// compileFlagRegexp compiles a regular expression from a command-line flag,
+// returning nil for an empty expression.
+func compileFlagRegexp(name, expr string) *regexp.Regexp {
+	if expr == "" {
+		return nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		log.Fatalf("invalid -%s: %v", name, err)
+	}
+	return re
+}
@@ -11,6 +11,7 @@ import "C"
 import (
 	"bytes"
 	"fmt"
+	"time"
 	"unsafe"
 )
 
@@ -167,6 +168,16 @@ func (dp DatasetProperty) Type() PropertyType {
 	return PropertyType(C.zfs_prop_get_type(C.zfs_prop_t(dp)))
 }
 
+// DatasetPropertyByName looks up a native dataset property by the name used
+// by `zfs get`, such as "used" or "compressratio".
+func DatasetPropertyByName(name string) (DatasetProperty, bool) {
+	csName := C.CString(name)
+	defer C.free(unsafe.Pointer(csName))
+
+	prop := DatasetProperty(C.zfs_name_to_prop(csName))
+	return prop, prop >= 0 && prop < DatasetNumProps
+}
+
 // Dataset - ZFS dataset object
 type Dataset struct {
 	handle *C.zfs_handle_t
@@ -192,12 +203,24 @@ func (d *Dataset) Type() DatasetType {
 	return DatasetType(C.zfs_get_type(d.handle))
 }
 
+// HasProperty reports whether the property applies to the dataset. Snapshots
+// only have the properties of the type of dataset they were taken of, so a
+// filesystem snapshot has no volsize.
+func (d *Dataset) HasProperty(prop DatasetProperty) bool {
+	return C.zfs_prop_valid_for_type(C.int(prop), C.zfs_get_type(d.handle), C.B_FALSE) == C.B_TRUE &&
+		C.zfs_prop_valid_for_type(C.int(prop), C.zfs_get_underlying_type(d.handle), C.B_TRUE) == C.B_TRUE
+}
+
 func (d *Dataset) Pool() *Pool {
 	return d.LibZFS().getPool(C.zfs_get_pool_handle(d.handle))
 }
 
 func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 	var ret, src C.int
+	// libzfs only resolves the source when given somewhere to write the name
+	// of the dataset that the value is inherited from
+	var statBuf = make([]byte, C.ZFS_MAX_DATASET_NAME_LEN)
+	statBufPtr := (*C.char)(unsafe.Pointer(&statBuf[0]))
 
 	switch prop.Type() {
 	case PropertyTypeNumber:
@@ -206,7 +229,7 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 			d.handle, C.zfs_prop_t(prop),
 			(*C.uint64_t)(unsafe.Pointer(&value)),
 			(*C.zprop_source_t)(unsafe.Pointer(&src)),
-			nil, 0,
+			statBufPtr, C.size_t(len(statBuf)),
 		)
 		if ret != 0 {
 			return nil, d.LibZFS().Errno()
@@ -214,6 +237,7 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 		return &DatasetPropertyNumber{
 			property: prop,
 			source:   PropertySource(src),
+			from:     inheritedFrom(src, statBuf),
 			value:    value,
 		}, nil
 
@@ -223,7 +247,7 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 			d.handle, C.zfs_prop_t(prop),
 			(*C.uint64_t)(unsafe.Pointer(&value)),
 			(*C.zprop_source_t)(unsafe.Pointer(&src)),
-			nil, 0,
+			statBufPtr, C.size_t(len(statBuf)),
 		)
 		if ret != 0 {
 			return nil, d.LibZFS().Errno()
@@ -231,6 +255,7 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 		return &DatasetPropertyIndex{
 			property: prop,
 			source:   PropertySource(src),
+			from:     inheritedFrom(src, statBuf),
 			value:    value,
 		}, nil
 
@@ -251,7 +276,7 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 			d.handle, C.zfs_prop_t(prop),
 			(*C.char)(unsafe.Pointer(&propBuf[0])), (C.ulong)(len(propBuf)),
 			(*C.zprop_source_t)(unsafe.Pointer(&src)),
-			nil, 0,
+			statBufPtr, C.size_t(len(statBuf)),
 			C.B_TRUE,
 		)
 
@@ -262,6 +287,7 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 		return &DatasetPropertyString{
 			property: prop,
 			source:   PropertySource(src),
+			from:     inheritedFrom(src, statBuf),
 			value:    string(propBuf[:bytes.IndexByte(propBuf, 0)]),
 		}, nil
 	}
@@ -269,6 +295,15 @@ func (d *Dataset) Get(prop DatasetProperty) (DatasetPropertyValue, error) {
 	panic("unknown property type")
 }
 
+// inheritedFrom returns the dataset name written to statBuf by libzfs for
+// inherited properties.
+func inheritedFrom(src C.int, statBuf []byte) string {
+	if PropertySource(src) != SourceInherited {
+		return ""
+	}
+	return string(statBuf[:bytes.IndexByte(statBuf, 0)])
+}
+
 func (d *Dataset) Gets(props ...DatasetProperty) (map[DatasetProperty]DatasetPropertyValue, error) {
 	vals := make(map[DatasetProperty]DatasetPropertyValue, len(props))
 
@@ -287,6 +322,108 @@ func (d *Dataset) Gets(props ...DatasetProperty) (map[DatasetProperty]DatasetPro
 	return vals, nil
 }
 
+// getStringOrEmpty gets a string property without a default, returning an
+// empty string if it isn't set. libzfs fails without saying why when such a
+// property is unset, so that's only assumed once the property is known to
+// apply to the dataset.
+func (d *Dataset) getStringOrEmpty(prop DatasetProperty) (string, error) {
+	if !d.HasProperty(prop) {
+		return "", fmt.Errorf("dataset '%s' has no %s property", d.Name(), prop)
+	}
+	val, err := d.Get(prop)
+	if err != nil {
+		return "", nil
+	}
+	return val.(*DatasetPropertyString).Value(), nil
+}
+
+// Origin returns the snapshot a clone was created from, or an empty string if
+// the dataset isn't a clone.
+func (d *Dataset) Origin() (string, error) {
+	return d.getStringOrEmpty(DatasetPropOrigin)
+}
+
+// ReceiveResumeToken returns the receive_resume_token of a filesystem or
+// volume, or an empty string if no resumable receive is pending.
+func (d *Dataset) ReceiveResumeToken() (string, error) {
+	return d.getStringOrEmpty(DatasetPropReceiveResumeToken)
+}
+
+// WrittenSince returns the space referenced by the dataset that was written
+// since the given snapshot, like the written@<snapshot> property. The snapshot
+// may be the origin of a clone.
+func (d *Dataset) WrittenSince(snapshot string) (uint64, error) {
+	csProp := C.CString("written@" + snapshot)
+	defer C.free(unsafe.Pointer(csProp))
+
+	var value C.uint64_t
+	// This is a plain ioctl, so the error is in errno rather than libzfs
+	ret, err := C.zfs_prop_get_written_int(d.handle, csProp, &value)
+	if ret != 0 {
+		return 0, fmt.Errorf("cannot get written@%s of '%s': %w", snapshot, d.Name(), err)
+	}
+	return uint64(value), nil
+}
+
+// Holds returns the user holds on a snapshot, which prevent it from being
+// destroyed, keyed by tag with the time each was placed.
+func (d *Dataset) Holds() (map[string]time.Time, error) {
+	var nvl *C.nvlist_t
+	if C.zfs_get_holds(d.handle, &nvl) != 0 {
+		return nil, d.LibZFS().Errno()
+	}
+	defer C.nvlist_free(nvl)
+
+	holds := make(map[string]time.Time)
+	for nvp := C.nvlist_next_nvpair(nvl, nil); nvp != nil; nvp = C.nvlist_next_nvpair(nvl, nvp) {
+		pair := NewNVPair(nvp)
+		if created, ok := pair.Uint64(); ok {
+			holds[pair.Name()] = time.Unix(int64(created), 0)
+		}
+	}
+	return holds, nil
+}
+
+// UserProperties returns all user-defined properties set on or inherited by
+// the dataset, keyed by name.
+func (d *Dataset) UserProperties() map[string]UserProperty {
+	name := d.Name()
+	nvl := NewNVList(C.zfs_get_user_props(d.handle))
+
+	props := make(map[string]UserProperty)
+	for nvp := C.nvlist_next_nvpair(nvl.handle, nil); nvp != nil; nvp = C.nvlist_next_nvpair(nvl.handle, nvp) {
+		pair := NewNVPair(nvp)
+		propval, ok := pair.NVList()
+		if !ok {
+			continue
+		}
+		value, err := propval.LookupString("value")
+		if err != nil {
+			continue
+		}
+		source, err := propval.LookupString("source")
+		if err != nil {
+			continue
+		}
+
+		// The source is the name of the dataset the property is set on, like
+		// libzfs's get_source() but without the help of zfs_prop_get()
+		prop := UserProperty{Value: value}
+		switch source {
+		case name:
+			prop.Source = SourceLocal
+		case "$recvd":
+			prop.Source = SourceReceived
+		default:
+			prop.Source = SourceInherited
+			prop.InheritedFrom = source
+		}
+		props[pair.Name()] = prop
+	}
+
+	return props
+}
+
 func (d *Dataset) Children(types DatasetType, depth int) ([]*Dataset, error) {
 	var handles list[*C.zfs_handle_t]
 	defer handles.clear()
@@ -0,0 +1,108 @@
+package zfs
+
+/*
+#include <libzfs.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// DDTObjectStats summarises the dedup tables (DDT) of a pool. The sizes are
+// averages per entry, as shown by `zpool status -D`.
+type DDTObjectStats struct {
+	Count  uint64 // Number of entries in the DDT
+	DSpace uint64 // Size of each entry on disk
+	MSpace uint64 // Size of each entry in core
+}
+
+// DDTStat is the block count and sizes for a set of dedup table entries.
+type DDTStat struct {
+	Blocks    uint64 // Allocated blocks
+	LSize     uint64 // Allocated logical size
+	PSize     uint64 // Allocated physical size
+	DSize     uint64 // Allocated deflated size
+	RefBlocks uint64 // Referenced blocks
+	RefLSize  uint64 // Referenced logical size
+	RefPSize  uint64 // Referenced physical size
+	RefDSize  uint64 // Referenced deflated size
+}
+
+// DDTHistogram buckets DDT entries by reference count. Bucket n holds entries
+// with a reference count in [2^n, 2^(n+1)).
+type DDTHistogram [64]DDTStat
+
+// DDTStats are the dedup statistics of a pool.
+type DDTStats struct {
+	Object    DDTObjectStats
+	Total     DDTStat
+	Histogram DDTHistogram
+}
+
+// DDTStats fetches the dedup table statistics for the pool. ErrNotFound is
+// returned if the pool doesn't report them, such as when it's faulted.
+func (p *Pool) DDTStats() (DDTStats, error) {
+	var ddo *C.ddt_object_t
+	var dds *C.ddt_stat_t
+	var ddh *C.ddt_histogram_t
+	var count C.uint_t
+
+	config, err := p.Config()
+	if err != nil {
+		return DDTStats{}, fmt.Errorf("failed to get zpool config: %w", err)
+	}
+
+	objStats := C.CString(PoolConfigDDTObjStats)
+	defer C.free(unsafe.Pointer(objStats))
+	ddtStats := C.CString(PoolConfigDDTStats)
+	defer C.free(unsafe.Pointer(ddtStats))
+	ddtHistogram := C.CString(PoolConfigDDTHistogram)
+	defer C.free(unsafe.Pointer(ddtHistogram))
+
+	// Here we "cheat" by unloading the uint64_t arrays into the ddt structs
+	// as the fields are all uint64_t and in the correct order for us already
+	ret := C.nvlist_lookup_uint64_array(config.Pointer(), objStats,
+		(**C.uint64_t)(unsafe.Pointer(&ddo)), &count)
+	if ret != 0 {
+		return DDTStats{}, nvlistLookupError(ret)
+	}
+	ret = C.nvlist_lookup_uint64_array(config.Pointer(), ddtStats,
+		(**C.uint64_t)(unsafe.Pointer(&dds)), &count)
+	if ret != 0 {
+		return DDTStats{}, nvlistLookupError(ret)
+	}
+	ret = C.nvlist_lookup_uint64_array(config.Pointer(), ddtHistogram,
+		(**C.uint64_t)(unsafe.Pointer(&ddh)), &count)
+	if ret != 0 {
+		return DDTStats{}, nvlistLookupError(ret)
+	}
+
+	stats := DDTStats{
+		Object: DDTObjectStats{
+			Count:  uint64(ddo.ddo_count),
+			DSpace: uint64(ddo.ddo_dspace),
+			MSpace: uint64(ddo.ddo_mspace),
+		},
+		Total: newDDTStat(dds),
+	}
+	for i := range stats.Histogram {
+		stats.Histogram[i] = newDDTStat(&ddh.ddh_stat[i])
+	}
+
+	return stats, nil
+}
+
+func newDDTStat(dds *C.ddt_stat_t) DDTStat {
+	return DDTStat{
+		Blocks:    uint64(dds.dds_blocks),
+		LSize:     uint64(dds.dds_lsize),
+		PSize:     uint64(dds.dds_psize),
+		DSize:     uint64(dds.dds_dsize),
+		RefBlocks: uint64(dds.dds_ref_blocks),
+		RefLSize:  uint64(dds.dds_ref_lsize),
+		RefPSize:  uint64(dds.dds_ref_psize),
+		RefDSize:  uint64(dds.dds_ref_dsize),
+	}
+}
@@ -0,0 +1,66 @@
+package zfs
+
+/*
+#include <libzfs.h>
+#include <zfeature_common.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PoolFeature is the state of a single pool feature flag, as shown by the
+// feature@ pool properties.
+type PoolFeature struct {
+	Name     string // User-facing feature name, e.g. "async_destroy"
+	GUID     string // On-disk feature identifier, e.g. "com.delphix:async_destroy"
+	State    string // One of FDISABLED, FENABLED or FACTIVE
+	Refcount uint64 // Number of on-disk references, non-zero when active
+}
+
+// Features returns the state of every feature known to libzfs for the pool.
+func (p *Pool) Features() ([]PoolFeature, error) {
+	config, err := p.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zpool config: %w", err)
+	}
+
+	// Pools older than feature flags (version < 5000) have no feature stats
+	// which leaves every feature disabled
+	stats, err := config.LookupNVList(PoolConfigFeatureStats)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to fetch feature stats: %w", err)
+	}
+	hasStats := err == nil
+
+	features := make([]PoolFeature, C.SPA_FEATURES)
+	for i := range features {
+		info := C.spa_feature_table[i]
+		feature := PoolFeature{
+			Name:  C.GoString(info.fi_uname),
+			GUID:  C.GoString(info.fi_guid),
+			State: FDISABLED,
+		}
+
+		// Mirrors zpool_prop_get_feature(): a feature is enabled when it has
+		// an entry and active when it's referenced
+		if hasStats {
+			refcount, err := stats.LookupUint64(feature.GUID)
+			if err == nil {
+				feature.State = FENABLED
+				feature.Refcount = refcount
+				if refcount > 0 {
+					feature.State = FACTIVE
+				}
+			} else if !errors.Is(err, ErrNotFound) {
+				return nil, fmt.Errorf("failed to read feature '%s': %w", feature.Name, err)
+			}
+		}
+
+		features[i] = feature
+	}
+
+	return features, nil
+}
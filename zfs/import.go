@@ -0,0 +1,137 @@
+package zfs
+
+/*
+#include <stdlib.h>
+#include <libzfs.h>
+#include <libzutil.h>
+
+static char **make_strings(int n) {
+	return calloc(n, sizeof(char *));
+}
+
+static void set_string(char **strs, int i, char *str) {
+	strs[i] = str;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// SearchImport scans the devices in dirs for pools that are available to be
+// imported, the same as `zpool import -d`. An empty dirs searches the default
+// device paths. Pools that are already imported or were destroyed are not
+// returned. Each pool must be closed.
+func (l *LibZFS) SearchImport(dirs []string) ([]ExportedPool, error) {
+	var args C.importargs_t
+	if len(dirs) > 0 {
+		paths := C.make_strings(C.int(len(dirs)))
+		defer C.free(unsafe.Pointer(paths))
+		for i, dir := range dirs {
+			cdir := C.CString(dir)
+			defer C.free(unsafe.Pointer(cdir))
+			C.set_string(paths, C.int(i), cdir)
+		}
+		args.path = paths
+		args.paths = C.int(len(dirs))
+	}
+
+	lpch := C.libpc_handle_t{
+		lpc_lib_handle: unsafe.Pointer(l.handle),
+		lpc_ops:        &C.libzfs_config_ops,
+		lpc_printerr:   C.B_FALSE,
+	}
+
+	l.lock.Lock()
+	pools := C.zpool_search_import(&lpch, &args)
+	l.lock.Unlock()
+	if pools == nil {
+		return nil, errors.New(C.GoString(C.libpc_error_description(&lpch)))
+	}
+	defer C.nvlist_free(pools)
+
+	// Each nvpair is named by the pool guid and contains its config, which is
+	// freed with the list so must be copied
+	var exported []ExportedPool
+	for nvp := C.nvlist_next_nvpair(pools, nil); nvp != nil; nvp = C.nvlist_next_nvpair(pools, nvp) {
+		config, ok := NewNVPair(nvp).NVList()
+		if !ok {
+			for i := range exported {
+				exported[i].Close()
+			}
+			return nil, fmt.Errorf("import candidate '%s' is not an nvlist", NewNVPair(nvp).Name())
+		}
+
+		var dup *C.nvlist_t
+		if ret := C.nvlist_dup(config.Pointer(), &dup, 0); ret != 0 {
+			for i := range exported {
+				exported[i].Close()
+			}
+			return nil, fmt.Errorf("failed to copy config of import candidate '%s': errno %d", NewNVPair(nvp).Name(), int(ret))
+		}
+
+		pool, err := l.newExportedPool(dup)
+		if err != nil {
+			C.nvlist_free(dup)
+			for i := range exported {
+				exported[i].Close()
+			}
+			return nil, err
+		}
+		// Like `zpool import` without -D
+		if pool.State == PoolStateDestroyed {
+			pool.Close()
+			continue
+		}
+		exported = append(exported, pool)
+	}
+
+	return exported, nil
+}
+
+func (l *LibZFS) newExportedPool(handle *C.nvlist_t) (ExportedPool, error) {
+	pool := ExportedPool{config: handle}
+	config := NewNVList(handle)
+	var err error
+
+	pool.Name, err = config.LookupString(PoolConfigPoolName)
+	if err != nil {
+		return ExportedPool{}, fmt.Errorf("failed to read pool name: %w", err)
+	}
+	pool.GUID, err = config.LookupUint64(PoolConfigPoolGUID)
+	if err != nil {
+		return ExportedPool{}, fmt.Errorf("failed to read guid of pool '%s': %w", pool.Name, err)
+	}
+	state, err := config.LookupUint64(PoolConfigPoolState)
+	if err != nil {
+		return ExportedPool{}, fmt.Errorf("failed to read state of pool '%s': %w", pool.Name, err)
+	}
+	pool.State = PoolState(state)
+
+	pool.Comment, err = config.LookupString(PoolConfigComment)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return ExportedPool{}, fmt.Errorf("failed to read comment of pool '%s': %w", pool.Name, err)
+	}
+
+	var msgid *C.char
+	var errata C.zpool_errata_t
+	pool.Status = PoolStatus(C.zpool_import_status(config.Pointer(), &msgid, &errata))
+
+	vdevs, err := config.LookupNVList(PoolConfigVdevTree)
+	if err != nil {
+		return ExportedPool{}, fmt.Errorf("failed to read vdev tree of pool '%s': %w", pool.Name, err)
+	}
+	pool.VDevs = VDevTree{nvl: vdevs, libzfs: l.handle}
+
+	mmp, err := lookupMMPInfo(config)
+	if err == nil {
+		pool.MMP = &mmp
+	} else if !errors.Is(err, ErrNotFound) {
+		return ExportedPool{}, fmt.Errorf("failed to read multihost state of pool '%s': %w", pool.Name, err)
+	}
+
+	return pool, nil
+}
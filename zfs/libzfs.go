@@ -9,12 +9,15 @@ package zfs
 
 #include <stdlib.h>
 #include <libzfs.h>
+#include <sys/systeminfo.h>
 */
 import "C"
 import (
 	"errors"
+	"strings"
 	"sync"
 	"syscall"
+	"unsafe"
 
 	"github.com/puzpuzpuz/xsync/v3"
 )
@@ -61,3 +64,28 @@ func (l *LibZFS) getDataset(handle *C.zfs_handle_t) *Dataset {
 	dataset, _ := allDatasets.LoadOrStore(handle, &Dataset{handle: handle})
 	return dataset
 }
+
+// VersionUserland returns the version of the libzfs library in use, such as
+// "2.2.4-1", without the "zfs-" prefix.
+func VersionUserland() string {
+	version := C.GoString(C.zfs_version_userland())
+	return strings.TrimPrefix(version, "zfs-")
+}
+
+// VersionKernel returns the version of the loaded zfs kernel module, such as
+// "2.2.4-1".
+func VersionKernel() (string, error) {
+	ptr, err := C.zfs_version_kernel()
+	if ptr == nil {
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(ptr))
+
+	return C.GoString(ptr), nil
+}
+
+// SystemHostID returns the hostid of the running system, as used by zfs to
+// record which host a pool was last imported on. Zero means it isn't set.
+func SystemHostID() uint64 {
+	return uint64(C.get_system_hostid())
+}
@@ -0,0 +1,266 @@
+package zfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// MMPState is the result of a multihost (MMP) activity check
+type MMPState uint64
+
+const (
+	MMPStateActive   MMPState = iota // In active use
+	MMPStateInactive                 // Inactive and safe to import
+	MMPStateNoHostID                 // System hostid is not set
+)
+
+func (s MMPState) String() string {
+	switch s {
+	case MMPStateActive:
+		return "active"
+	case MMPStateInactive:
+		return "inactive"
+	case MMPStateNoHostID:
+		return "nohostid"
+	default:
+		return "unknown"
+	}
+}
+
+// MMPInfo is the outcome of the multihost activity check performed when a
+// pool with multihost=on is found by LibZFS.SearchImport, describing the host
+// that last wrote to it.
+type MMPInfo struct {
+	State    MMPState
+	TXG      uint64 // TXG of the last MMP write observed
+	Seq      uint16 // Sequence number of the last MMP write observed
+	Hostname string // Hostname of the system using the pool, if active
+	HostID   uint64 // Hostid of the system using the pool, if active
+}
+
+// lookupMMPInfo reads the multihost activity check results from the load
+// info of a pool config. This is only present in the configs of pools found by
+// an import search, where the kernel has performed an activity check,
+// otherwise ErrNotFound is returned.
+func lookupMMPInfo(config NVList) (MMPInfo, error) {
+	info, err := config.LookupNVList(PoolConfigLoadInfo)
+	if err != nil {
+		return MMPInfo{}, err
+	}
+
+	state, err := info.LookupUint64(PoolConfigMmpState)
+	if err != nil {
+		return MMPInfo{}, err
+	}
+
+	mmp := MMPInfo{State: MMPState(state)}
+
+	// All of the following are optional, depending on the state
+	mmp.TXG, err = info.LookupUint64(PoolConfigMmpTXG)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return MMPInfo{}, err
+	}
+	mmp.Seq, err = info.LookupUint16(PoolConfigMmpSeq)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return MMPInfo{}, err
+	}
+	mmp.Hostname, err = info.LookupString(PoolConfigMmpHostname)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return MMPInfo{}, err
+	}
+	mmp.HostID, err = info.LookupUint64(PoolConfigMmpHostID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return MMPInfo{}, err
+	}
+
+	return mmp, nil
+}
+
+// Uberblock layout, from sys/uberblock_impl.h and sys/vdev_impl.h
+const (
+	uberblockMagic      = 0x00bab10c
+	uberblockShift      = 10 // Minimum uberblock slot size
+	uberblockMaxShift   = 13 // Maximum uberblock slot size
+	uberblockMaxSize    = 1 << uberblockMaxShift
+	mmpMagic            = 0xa11cea11
+	mmpSeqValid         = 0x02
+	vdevLabelSize       = 256 << 10
+	vdevLabels          = 4
+	vdevUberblockOffset = 128 << 10 // Offset of the uberblock ring in a label
+	vdevUberblockRing   = 128 << 10
+)
+
+// MMPWrite is a multihost write, the uberblock that the host with the pool
+// imported rewrites periodically so that other hosts can see it's in use.
+type MMPWrite struct {
+	TXG       uint64 // TXG of the uberblock written, the last synced
+	Seq       uint16 // Number of MMP writes since that TXG was synced
+	Timestamp time.Time
+}
+
+// LastMMPWrite finds the most recent multihost write to an imported pool, as
+// seen by the activity check of another host. These are read from the last
+// uberblock slot in each label of each leaf vdev, which is reserved for them,
+// so this needs read access to the devices. ErrNotFound is returned if there
+// are none, such as when multihost is off.
+func (p *Pool) LastMMPWrite() (MMPWrite, error) {
+	root, err := p.VDevTree()
+	if err != nil {
+		return MMPWrite{}, err
+	}
+
+	var last MMPWrite
+	found := false
+	var errs []error
+	for _, top := range root.Children() {
+		ashift, err := top.Config().LookupUint64(PoolConfigAShift)
+		if err != nil {
+			return MMPWrite{}, fmt.Errorf("failed to read ashift of vdev '%s': %w", top.Name(), err)
+		}
+		shift := min(max(ashift, uberblockShift), uberblockMaxShift)
+
+		for _, leaf := range leafVDevs(top) {
+			write, ok, err := readMMPWrite(leaf.Path(), shift)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok && (!found || write.after(last)) {
+				last = write
+				found = true
+			}
+		}
+	}
+
+	// Any readable leaf will do, as each is written to in turn
+	if !found && len(errs) > 0 {
+		return MMPWrite{}, errors.Join(errs...)
+	} else if !found {
+		return MMPWrite{}, ErrNotFound
+	}
+	return last, nil
+}
+
+// after orders writes the same way as vdev_uberblock_compare().
+func (w MMPWrite) after(other MMPWrite) bool {
+	if w.TXG != other.TXG {
+		return w.TXG > other.TXG
+	}
+	if !w.Timestamp.Equal(other.Timestamp) {
+		return w.Timestamp.After(other.Timestamp)
+	}
+	return w.Seq > other.Seq
+}
+
+// leafVDevs returns the disk and file vdevs below vdt that are present.
+func leafVDevs(vdt VDevTree) []VDevTree {
+	children := vdt.Children()
+	if len(children) == 0 {
+		if _, err := vdt.Config().LookupUint64(PoolConfigNotPresent); err == nil {
+			return nil
+		}
+		switch vdt.Type() {
+		case VDevTypeDisk, VDevTypeFile:
+			return []VDevTree{vdt}
+		default:
+			return nil
+		}
+	}
+
+	var leaves []VDevTree
+	for _, child := range children {
+		leaves = append(leaves, leafVDevs(child)...)
+	}
+	return leaves
+}
+
+// readMMPWrite reads the MMP uberblock slot of each label of a leaf vdev,
+// returning the most recent valid write.
+func readMMPWrite(path string, shift uint64) (MMPWrite, bool, error) {
+	// The kernel writes to the device directly, so the page cache would be
+	// stale. Files on filesystems without O_DIRECT are read as they are.
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_DIRECT, 0)
+	if errors.Is(err, syscall.EINVAL) {
+		file, err = os.Open(path)
+	}
+	if err != nil {
+		return MMPWrite{}, false, err
+	}
+	defer file.Close()
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return MMPWrite{}, false, fmt.Errorf("failed to find size of '%s': %w", path, err)
+	}
+	// Labels 2 and 3 are at the end of the device, rounded down to a label
+	psize := size &^ (vdevLabelSize - 1)
+	if psize < vdevLabels*vdevLabelSize {
+		return MMPWrite{}, false, fmt.Errorf("vdev '%s' is too small for labels", path)
+	}
+
+	// O_DIRECT needs a buffer aligned to the device's block size, which is
+	// at most the 8K of the largest slot
+	slotSize := 1 << shift
+	mem := make([]byte, slotSize+uberblockMaxSize)
+	align := int(uintptr(unsafe.Pointer(&mem[0])) & (uberblockMaxSize - 1))
+	buf := mem[(uberblockMaxSize-align)%uberblockMaxSize:][:slotSize]
+
+	var last MMPWrite
+	found := false
+	for l := int64(0); l < vdevLabels; l++ {
+		offset := l*vdevLabelSize + vdevUberblockOffset + vdevUberblockRing - int64(slotSize)
+		if l >= vdevLabels/2 {
+			offset += psize - vdevLabels*vdevLabelSize
+		}
+		if _, err := file.ReadAt(buf, offset); err != nil {
+			return MMPWrite{}, false, fmt.Errorf("failed to read label %d of '%s': %w", l, path, err)
+		}
+
+		write, ok := parseMMPUberblock(buf)
+		if ok && (!found || write.after(last)) {
+			last = write
+			found = true
+		}
+	}
+	return last, found, nil
+}
+
+// parseMMPUberblock decodes an uberblock written by a multihost write, which
+// is in the byte order of the host that wrote it.
+func parseMMPUberblock(buf []byte) (MMPWrite, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	switch {
+	case binary.LittleEndian.Uint64(buf) == uberblockMagic:
+	case binary.BigEndian.Uint64(buf) == uberblockMagic:
+		order = binary.BigEndian
+	default:
+		return MMPWrite{}, false
+	}
+
+	/*
+		ub_magic, ub_version, ub_txg, ub_guid_sum, ub_timestamp, then the
+		128 byte ub_rootbp, ub_software_version, ub_mmp_magic, ub_mmp_delay
+		and ub_mmp_config
+	*/
+	txg := order.Uint64(buf[16:])
+	timestamp := order.Uint64(buf[32:])
+	if order.Uint64(buf[176:]) != mmpMagic {
+		return MMPWrite{}, false
+	}
+	config := order.Uint64(buf[192:])
+	if config&mmpSeqValid == 0 {
+		return MMPWrite{}, false
+	}
+
+	return MMPWrite{
+		TXG:       txg,
+		Seq:       uint16(config >> 32),
+		Timestamp: time.Unix(int64(timestamp), 0),
+	}, true
+}
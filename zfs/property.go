@@ -6,6 +6,7 @@ package zfs
 */
 import "C"
 import (
+	"strconv"
 	"strings"
 	"unsafe"
 )
@@ -69,11 +70,15 @@ type DatasetPropertyValue interface {
 	Type() PropertyType
 	Property() DatasetProperty
 	Source() PropertySource
+	// InheritedFrom returns the name of the dataset that the value is
+	// inherited from, if the source is SourceInherited.
+	InheritedFrom() string
 }
 
 type DatasetPropertyNumber struct {
 	property DatasetProperty
 	source   PropertySource
+	from     string
 	value    uint64
 }
 
@@ -89,6 +94,10 @@ func (d DatasetPropertyNumber) Source() PropertySource {
 	return d.source
 }
 
+func (d DatasetPropertyNumber) InheritedFrom() string {
+	return d.from
+}
+
 func (d DatasetPropertyNumber) Value() uint64 {
 	return d.value
 }
@@ -96,6 +105,7 @@ func (d DatasetPropertyNumber) Value() uint64 {
 type DatasetPropertyIndex struct {
 	property DatasetProperty
 	source   PropertySource
+	from     string
 	value    uint64
 }
 
@@ -111,6 +121,12 @@ func (d DatasetPropertyIndex) Source() PropertySource {
 	return d.source
 }
 
+func (d DatasetPropertyIndex) InheritedFrom() string {
+	return d.from
+}
+
+// Name returns the name of the index value, such as "lz4" for compression, or
+// the number itself if libzfs doesn't know it.
 func (d DatasetPropertyIndex) Name() string {
 	var cstr *C.char
 	ret := C.zfs_prop_index_to_string(
@@ -119,7 +135,8 @@ func (d DatasetPropertyIndex) Name() string {
 		(**C.char)(unsafe.Pointer(&cstr)),
 	)
 	if ret != 0 {
-		panic("womp")
+		// Values from a newer kernel module than the library
+		return strconv.FormatUint(d.value, 10)
 	}
 	return C.GoString(cstr)
 }
@@ -131,6 +148,7 @@ func (d DatasetPropertyIndex) Value() uint64 {
 type DatasetPropertyString struct {
 	property DatasetProperty
 	source   PropertySource
+	from     string
 	value    string
 }
 
@@ -146,6 +164,20 @@ func (d DatasetPropertyString) Source() PropertySource {
 	return d.source
 }
 
+func (d DatasetPropertyString) InheritedFrom() string {
+	return d.from
+}
+
 func (d DatasetPropertyString) Value() string {
 	return d.value
 }
+
+// UserProperty is the value of a user-defined property, such as
+// "com.sun:auto-snapshot", which are free-form strings.
+type UserProperty struct {
+	Value  string
+	Source PropertySource
+	// InheritedFrom names the dataset that the value is inherited from, if
+	// Source is SourceInherited.
+	InheritedFrom string
+}
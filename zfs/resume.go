@@ -0,0 +1,65 @@
+package zfs
+
+/*
+#include <stdlib.h>
+#include <libzfs.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ResumeToken is the decoded receive_resume_token of a dataset with an
+// interrupted resumable receive, as shown by `zfs send -nvt`.
+type ResumeToken struct {
+	// FromGUID is the GUID of the incremental source snapshot, or zero if
+	// the stream is a full one
+	FromGUID uint64
+	// ToGUID and ToName identify the snapshot being received, as named on
+	// the sending side
+	ToGUID uint64
+	ToName string
+	// Object and Offset are where the stream will resume from
+	Object uint64
+	Offset uint64
+	// Bytes is how much of the stream has been received
+	Bytes uint64
+}
+
+// DecodeResumeToken decodes a receive_resume_token.
+func (l *LibZFS) DecodeResumeToken(token string) (ResumeToken, error) {
+	csToken := C.CString(token)
+	defer C.free(unsafe.Pointer(csToken))
+
+	handle := C.zfs_send_resume_token_to_nvlist(l.handle, csToken)
+	if handle == nil {
+		// libzfs only describes why in the auxiliary message
+		return ResumeToken{}, errors.New("invalid receive resume token")
+	}
+	defer C.nvlist_free(handle)
+	nvl := NewNVList(handle)
+
+	var rt ResumeToken
+	var err error
+	// Only present for incremental streams
+	rt.FromGUID, _ = nvl.LookupUint64("fromguid")
+	if rt.ToGUID, err = nvl.LookupUint64("toguid"); err != nil {
+		return ResumeToken{}, fmt.Errorf("failed to read resume token 'toguid': %w", err)
+	}
+	if rt.ToName, err = nvl.LookupString("toname"); err != nil {
+		return ResumeToken{}, fmt.Errorf("failed to read resume token 'toname': %w", err)
+	}
+	if rt.Object, err = nvl.LookupUint64("object"); err != nil {
+		return ResumeToken{}, fmt.Errorf("failed to read resume token 'object': %w", err)
+	}
+	if rt.Offset, err = nvl.LookupUint64("offset"); err != nil {
+		return ResumeToken{}, fmt.Errorf("failed to read resume token 'offset': %w", err)
+	}
+	if rt.Bytes, err = nvl.LookupUint64("bytes"); err != nil {
+		return ResumeToken{}, fmt.Errorf("failed to read resume token 'bytes': %w", err)
+	}
+	return rt, nil
+}
@@ -0,0 +1,122 @@
+package zfs
+
+/*
+#include "list.h"
+#include <stdlib.h>
+#include <string.h>
+#include <libzfs.h>
+
+struct userspace_entry {
+	char *domain;
+	uid_t rid;
+	uint64_t space;
+};
+
+static int userspace_append(void *arg, const char *domain, uid_t rid, uint64_t space) {
+	struct userspace_entry *e = malloc(sizeof(*e));
+	e->domain = (domain != NULL && domain[0] != '\0') ? strdup(domain) : NULL;
+	e->rid = rid;
+	e->space = space;
+	return list_append(e, arg);
+}
+
+static int userspace_list(zfs_handle_t *zhp, zfs_userquota_prop_t type, struct list *l) {
+	return zfs_userspace(zhp, type, userspace_append, l);
+}
+*/
+import "C"
+import (
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// UserSpaceType selects the accounting returned by Dataset.UserSpace, which
+// is either space or objects (files), used or quota, by user, group or
+// project.
+type UserSpaceType int
+
+const (
+	UserSpaceUserUsed UserSpaceType = iota
+	UserSpaceUserQuota
+	UserSpaceGroupUsed
+	UserSpaceGroupQuota
+	UserSpaceUserObjUsed
+	UserSpaceUserObjQuota
+	UserSpaceGroupObjUsed
+	UserSpaceGroupObjQuota
+	UserSpaceProjectUsed
+	UserSpaceProjectQuota
+	UserSpaceProjectObjUsed
+	UserSpaceProjectObjQuota
+
+	UserSpaceNumTypes
+)
+
+// String returns the property name prefix of the type, such as "userused".
+func (t UserSpaceType) String() string {
+	return strings.TrimSuffix(C.GoString(C.zfs_userquota_prop_prefixes[t]), "@")
+}
+
+// optional is whether the accounting depends on a pool feature, rather than
+// always being present.
+func (t UserSpaceType) optional() bool {
+	switch t {
+	case UserSpaceUserUsed, UserSpaceUserQuota, UserSpaceGroupUsed, UserSpaceGroupQuota:
+		return false
+	default:
+		return true
+	}
+}
+
+// UserSpace is a single user, group or project's entry in the space
+// accounting of a dataset.
+type UserSpace struct {
+	// Domain is the SMB domain of the ID, if it is a SID rather than a POSIX
+	// ID. It is always empty on Linux.
+	Domain string
+	ID     uint32
+	// Value is in bytes for space, or a count for objects
+	Value uint64
+}
+
+// UserSpace returns the space accounting of the given type for a filesystem,
+// like `zfs userspace`. Object and project accounting return nothing if the
+// filesystem doesn't have it, such as when the userobj_accounting or
+// project_quota features aren't active.
+func (d *Dataset) UserSpace(typ UserSpaceType) ([]UserSpace, error) {
+	var entries list[*C.struct_userspace_entry]
+	defer entries.clear()
+	defer entries.iter(func(_ int, e *C.struct_userspace_entry) error {
+		C.free(unsafe.Pointer(e.domain))
+		C.free(unsafe.Pointer(e))
+		return nil
+	})
+
+	ret := C.userspace_list(d.handle, C.zfs_userquota_prop_t(typ), (*C.struct_list)(entries.pointer()))
+	if ret != 0 {
+		err := d.LibZFS().Errno()
+		// Recent libzfs ignores the ENOTSUP itself, but older versions report
+		// it as EZFS_BADVERSION
+		var zerr *Error
+		if typ.optional() && errors.As(err, &zerr) && zerr.Errno() == EBadversion {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	space := make([]UserSpace, 0, entries.len())
+	_ = entries.iter(func(_ int, e *C.struct_userspace_entry) error {
+		var domain string
+		if e.domain != nil {
+			domain = C.GoString(e.domain)
+		}
+		space = append(space, UserSpace{
+			Domain: domain,
+			ID:     uint32(e.rid),
+			Value:  uint64(e.space),
+		})
+		return nil
+	})
+	return space, nil
+}
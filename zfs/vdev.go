@@ -95,6 +95,8 @@ const (
 type VDevTree struct {
 	pool *Pool
 	nvl  NVList
+	// libzfs names the vdevs of pools that aren't imported, which have no pool
+	libzfs *C.libzfs_handle_t
 
 	// cached after first use
 	name string
@@ -135,7 +137,11 @@ func (vdt VDevTree) Name() string {
 
 	// Flag VDEV_NAME_TYPE_ID gives `raidz1-n` where n is the vdev-id. Without
 	// it (flag value of zero), we'd simply get `raidz1` which isn't unique.
-	ptr := C.zpool_vdev_name(C.zpool_get_handle(vdt.pool.handle), vdt.pool.handle, vdt.nvl.handle, C.VDEV_NAME_TYPE_ID)
+	hdl, zhp := vdt.libzfs, (*C.zpool_handle_t)(nil)
+	if vdt.pool != nil {
+		hdl, zhp = C.zpool_get_handle(vdt.pool.handle), vdt.pool.handle
+	}
+	ptr := C.zpool_vdev_name(hdl, zhp, vdt.nvl.handle, C.VDEV_NAME_TYPE_ID)
 	if ptr == nil {
 		panic("zpool_vdev_name() returned nil")
 	}
@@ -198,6 +204,7 @@ func (vdt VDevTree) Children() []VDevTree {
 	for i, nvl := range nvls {
 		children[i].pool = vdt.pool
 		children[i].nvl = nvl
+		children[i].libzfs = vdt.libzfs
 	}
 
 	return children
@@ -10,6 +10,7 @@ import "C"
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -67,10 +68,12 @@ func (pp PoolProperty) Type() PropertyType {
 	return PropertyType(C.zpool_prop_get_type(C.zpool_prop_t(pp)))
 }
 
-// Enable or disable pool feature with this constants
+// Pool feature states. Enable or disable pool feature with FENABLED and
+// FDISABLED; FACTIVE is only reported for enabled features that are in use.
 const (
 	FENABLED  = "enabled"
 	FDISABLED = "disabled"
+	FACTIVE   = "active"
 )
 
 /*
@@ -247,6 +250,51 @@ func (ps PoolState) String() string {
 	return strings.ToLower(str)
 }
 
+// FailureMode is the behaviour of the pool on catastrophic failure, as set by
+// the failmode pool property
+type FailureMode uint64
+
+const (
+	FailureModeWait     FailureMode = iota // Block I/O until the pool recovers
+	FailureModeContinue                    // Return EIO to new I/O
+	FailureModePanic                       // Panic the system
+)
+
+func (fm FailureMode) String() string {
+	switch fm {
+	case FailureModeWait:
+		return "wait"
+	case FailureModeContinue:
+		return "continue"
+	case FailureModePanic:
+		return "panic"
+	default:
+		return "unknown"
+	}
+}
+
+// SuspendReason is why I/O to a pool has been suspended
+type SuspendReason uint64
+
+const (
+	SuspendNone  SuspendReason = iota // Not suspended
+	SuspendIOErr                      // Failed I/O
+	SuspendMMP                        // Failed multihost (MMP) writes
+)
+
+func (sr SuspendReason) String() string {
+	switch sr {
+	case SuspendNone:
+		return "none"
+	case SuspendIOErr:
+		return "ioerr"
+	case SuspendMMP:
+		return "mmp"
+	default:
+		return "unknown"
+	}
+}
+
 // PoolScanStat - Pool scan statistics
 type PoolScanStat struct {
 	// Values stored on disk
@@ -265,7 +313,8 @@ type PoolScanStat struct {
 	PassScrubPause time.Time
 }
 
-// ExportedPool is type representing ZFS pool available for import
+// ExportedPool is type representing ZFS pool available for import, as found by
+// LibZFS.SearchImport. It must be closed to free its config.
 type ExportedPool struct {
 	VDevs   VDevTree
 	Name    string
@@ -273,6 +322,15 @@ type ExportedPool struct {
 	GUID    uint64
 	State   PoolState
 	Status  PoolStatus
+	MMP     *MMPInfo // Multihost activity check result, if one was performed
+
+	config *C.nvlist_t
+}
+
+// Close frees the pool config, which VDevs refers to.
+func (p *ExportedPool) Close() {
+	C.nvlist_free(p.config)
+	p.config = nil
 }
 
 // PoolPropertyValue ZFS pool property value
@@ -331,6 +389,50 @@ func (p *Pool) Config() (NVList, error) {
 	return NewNVList(config), nil
 }
 
+// Suspended returns why I/O to the pool is suspended, or SuspendNone if it
+// isn't.
+func (p *Pool) Suspended() (SuspendReason, error) {
+	config, err := p.Config()
+	if err != nil {
+		return SuspendNone, fmt.Errorf("failed to get zpool config: %w", err)
+	}
+
+	// The value of ZPOOL_CONFIG_SUSPENDED is the failmode in effect when the
+	// pool was suspended, which is also the failmode property
+	_, err = config.LookupUint64(PoolConfigSuspended)
+	if errors.Is(err, ErrNotFound) {
+		return SuspendNone, nil
+	} else if err != nil {
+		return SuspendNone, err
+	}
+
+	reason, err := config.LookupUint64(PoolConfigSuspendedReason)
+	if err != nil {
+		return SuspendNone, err
+	}
+
+	return SuspendReason(reason), nil
+}
+
+// LoadedTime returns when the pool was loaded (imported) into the kernel.
+func (p *Pool) LoadedTime() (time.Time, error) {
+	config, err := p.Config()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get zpool config: %w", err)
+	}
+
+	// Stored as { tv_sec, tv_nsec }
+	loaded, err := config.LookupUint64Array(PoolConfigLoadedTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(loaded) != 2 {
+		return time.Time{}, fmt.Errorf("unexpected '%s' length %d", PoolConfigLoadedTime, len(loaded))
+	}
+
+	return time.Unix(int64(loaded[0]), int64(loaded[1])).UTC(), nil
+}
+
 // VDevTree - Fetch pool's current vdev tree configuration, state and stats
 func (p *Pool) VDevTree() (VDevTree, error) {
 	config, err := p.Config()